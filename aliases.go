@@ -0,0 +1,224 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// maxAliasDistance is the largest edit distance between two names that are
+// suggested as aliases. It is also capped at a fifth of the shorter name, so
+// short names like "Ann" and "Dan" aren't paired.
+const maxAliasDistance = 2
+
+// Identity is a name and email pair as recorded in commits
+type Identity struct {
+	Name    string `json:"name"`
+	Email   string `json:"email"`
+	Commits int    `json:"commits"`
+}
+
+// mailmapForm formats the identity as it's written in .mailmap
+func (id Identity) mailmapForm() string {
+	return fmt.Sprintf("%s <%s>", id.Name, id.Email)
+}
+
+// AliasGroup is a set of identities that likely belong to one person. The
+// canonical identity is the one with the most commits.
+type AliasGroup struct {
+	Canonical Identity   `json:"canonical"`
+	Aliases   []Identity `json:"aliases"`
+	Mailmap   []string   `json:"mailmap"`
+}
+
+// identities lists the distinct name and email pairs in the history, with
+// .mailmap already applied, so entries that are already mapped aren't
+// suggested again
+func (ga *GitAnalyzer) identities(ctx context.Context) ([]Identity, error) {
+	format := "--format=%aN%x00%aE"
+	if ga.config.Credit == CreditCommitter {
+		format = "--format=%cN%x00%cE"
+	}
+
+	args := []string{"log", format}
+	if ga.config.DateSince != "" {
+		args = append(args, "--since="+ga.config.DateSince)
+	}
+	if ga.config.DateUntil != "" {
+		args = append(args, "--until="+ga.config.DateUntil)
+	}
+
+	output, err := ga.gitCommand(ctx, args...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("git log: %w", err)
+	}
+
+	counts := make(map[Identity]int)
+	for line := range strings.SplitSeq(strings.TrimSpace(string(output)), "\n") {
+		name, email, ok := strings.Cut(line, "\x00")
+		if !ok {
+			continue
+		}
+		counts[Identity{Name: decodeGitName(name), Email: email}]++
+	}
+
+	ids := make([]Identity, 0, len(counts))
+	for id, n := range counts {
+		id.Commits = n
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if ids[i].Commits != ids[j].Commits {
+			return ids[i].Commits > ids[j].Commits
+		}
+		if ids[i].Name != ids[j].Name {
+			return ids[i].Name < ids[j].Name
+		}
+		return ids[i].Email < ids[j].Email
+	})
+
+	return ids, nil
+}
+
+// normalizeAliasName makes names comparable, ignoring case and spacing
+func normalizeAliasName(name string) string {
+	return strings.Join(strings.Fields(strings.ToLower(name)), " ")
+}
+
+// levenshtein returns the number of single-rune insertions, deletions and
+// substitutions needed to turn a into b
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+
+	return prev[len(rb)]
+}
+
+// similarNames reports whether two names are close enough to be aliases
+func similarNames(a, b string) bool {
+	a, b = normalizeAliasName(a), normalizeAliasName(b)
+	if a == "" || b == "" {
+		return false
+	}
+	shortest := min(len([]rune(a)), len([]rune(b)))
+	return levenshtein(a, b) <= min(maxAliasDistance, shortest/5)
+}
+
+// groupAliases clusters identities that share an email address, ignoring
+// case, or have similar names. Identities are expected most commits first,
+// which makes the first of each group its canonical identity.
+func groupAliases(ids []Identity) []AliasGroup {
+	parent := make([]int, len(ids))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	union := func(i, j int) {
+		ri, rj := find(i), find(j)
+		if ri == rj {
+			return
+		}
+		// The root with the lower index has more commits
+		if rj < ri {
+			ri, rj = rj, ri
+		}
+		parent[rj] = ri
+	}
+
+	for i := range ids {
+		for j := i + 1; j < len(ids); j++ {
+			sameEmail := ids[i].Email != "" && strings.EqualFold(ids[i].Email, ids[j].Email)
+			if sameEmail || similarNames(ids[i].Name, ids[j].Name) {
+				union(i, j)
+			}
+		}
+	}
+
+	members := make(map[int][]Identity)
+	var roots []int
+	for i, id := range ids {
+		root := find(i)
+		if root == i {
+			roots = append(roots, i)
+			continue
+		}
+		members[root] = append(members[root], id)
+	}
+
+	var groups []AliasGroup
+	for _, root := range roots {
+		if len(members[root]) == 0 {
+			continue
+		}
+		group := AliasGroup{Canonical: ids[root], Aliases: members[root]}
+		for _, alias := range group.Aliases {
+			group.Mailmap = append(group.Mailmap,
+				group.Canonical.mailmapForm()+" "+alias.mailmapForm())
+		}
+		groups = append(groups, group)
+	}
+
+	return groups
+}
+
+// detectAliases suggests .mailmap entries for authors that likely appear
+// under several names or emails. Counts are not affected.
+func (ga *GitAnalyzer) detectAliases(ctx context.Context) ([]AliasGroup, error) {
+	ids, err := ga.identities(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return groupAliases(ids), nil
+}
+
+// writeMailmap writes the suggested .mailmap lines
+func writeMailmap(w io.Writer, groups []AliasGroup) {
+	for _, group := range groups {
+		for _, line := range group.Mailmap {
+			fmt.Fprintln(w, line)
+		}
+	}
+}
+
+// displayAliases displays suggested .mailmap entries for likely duplicate
+// authors
+func (ga *GitAnalyzer) displayAliases(w io.Writer, result *AnalysisResult) {
+	if !ga.config.DetectAliases {
+		return
+	}
+
+	if !ga.config.Quiet {
+		fmt.Fprintf(w, "\n%s\n", ga.styleHeader("Possible Duplicate Authors"))
+		if len(result.Aliases) == 0 {
+			fmt.Fprintln(w, "No likely duplicates found")
+			return
+		}
+		fmt.Fprintln(w, "Suggested .mailmap entries (review before adding):")
+	}
+
+	writeMailmap(w, result.Aliases)
+}
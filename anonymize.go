@@ -0,0 +1,85 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// newAnonymizeSalt generates a random salt, so identifiers are stable within
+// a run but can't be matched against another run or a list of names
+func newAnonymizeSalt() (string, error) {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(salt), nil
+}
+
+// anonymize replaces an author name with a stable identifier derived from a
+// salted hash, such as "author-1a2b3c4d". Names stay distinct, so
+// concentration metrics are preserved.
+func (ga *GitAnalyzer) anonymize(name string) string {
+	if !ga.config.Anonymize || (ga.config.MergeBots && name == BotsLabel) {
+		return name
+	}
+	mac := hmac.New(sha256.New, []byte(ga.config.AnonymizeSalt))
+	mac.Write([]byte(name))
+	return "author-" + hex.EncodeToString(mac.Sum(nil)[:4])
+}
+
+// anonymizeResult replaces every author name in a result. Authors are
+// already sorted by their identifiers with --sort name, and submodule results
+// are anonymized by their own analyzers.
+func (ga *GitAnalyzer) anonymizeResult(result *AnalysisResult) {
+	renameAuthors(result, ga.anonymize)
+}
+
+// renameAuthors replaces every author name in a result with rename's
+func renameAuthors(result *AnalysisResult, rename func(string) string) {
+	for i := range result.Authors {
+		result.Authors[i].Name = rename(result.Authors[i].Name)
+	}
+	for i := range result.DiffFiles {
+		for j := range result.DiffFiles[i].Owners {
+			result.DiffFiles[i].Owners[j].Name = rename(result.DiffFiles[i].Owners[j].Name)
+		}
+	}
+	for i := range result.History {
+		result.History[i].Name = rename(result.History[i].Name)
+	}
+	if h := result.Highlights; h != nil {
+		for _, a := range []*AuthorHighlight{h.MostActive, h.Oldest, h.Newest} {
+			if a != nil {
+				a.Name = rename(a.Name)
+			}
+		}
+		if h.TopDirectory != nil {
+			h.TopDirectory.Owner = rename(h.TopDirectory.Owner)
+		}
+	}
+	for i := range result.Teams {
+		for j, member := range result.Teams[i].Members {
+			result.Teams[i].Members[j] = rename(member)
+		}
+	}
+	for i := range result.CoOwnership {
+		result.CoOwnership[i].Source = rename(result.CoOwnership[i].Source)
+		result.CoOwnership[i].Target = rename(result.CoOwnership[i].Target)
+	}
+	for i := range result.Tiers {
+		for j, member := range result.Tiers[i].Members {
+			result.Tiers[i].Members[j] = rename(member)
+		}
+	}
+	for i := range result.Directories {
+		for j := range result.Directories[i].TopAuthors {
+			owner := &result.Directories[i].TopAuthors[j]
+			owner.Name = rename(owner.Name)
+		}
+	}
+	for i := range result.OrphanedFiles {
+		result.OrphanedFiles[i].TopAuthor = rename(result.OrphanedFiles[i].TopAuthor)
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// loadNameCasings picks the display name of each author whose name appears
+// in several casings in the history, such as "JOHN DOE" and "John Doe", for
+// --merge-case-insensitive. The casing with the most commits wins, ties
+// going to the one used most recently. Names come from git log with .mailmap
+// applied, like the names blame reports.
+func (ga *GitAnalyzer) loadNameCasings(ctx context.Context) error {
+	format := "--format=%aN"
+	if ga.config.Credit == CreditCommitter {
+		format = "--format=%cN"
+	}
+	args := []string{"log", format}
+	if ga.config.AllBranches {
+		args = append(args, "--all")
+	}
+
+	output, err := ga.gitCommand(ctx, args...).Output()
+	if err != nil {
+		return fmt.Errorf("git log: %w", err)
+	}
+
+	// Commits are listed newest first, so the first casing to reach the
+	// highest count is the most recently used among the tied ones
+	counts := make(map[string]int)
+	ga.nameCasings = make(map[string]string)
+	best := make(map[string]int)
+	for line := range strings.SplitSeq(strings.TrimSpace(string(output)), "\n") {
+		name := decodeGitName(line)
+		if strings.TrimSpace(name) == "" {
+			continue
+		}
+		counts[name]++
+		key := strings.ToLower(name)
+		if counts[name] > best[key] {
+			best[key] = counts[name]
+			ga.nameCasings[key] = name
+		}
+	}
+
+	ga.logDebug("Found %d distinct author names, %d ignoring case", len(counts), len(ga.nameCasings))
+	return nil
+}
+
+// foldCase returns the display name an author is merged under with
+// --merge-case-insensitive, or the name unchanged without it. Names missing
+// from the history, such as the unknown-author label, keep their casing.
+func (ga *GitAnalyzer) foldCase(author string) string {
+	if ga.nameCasings == nil {
+		return author
+	}
+	if name, ok := ga.nameCasings[strings.ToLower(author)]; ok {
+		return name
+	}
+	return author
+}
+
+// isUser reports whether blamed lines by author belong to the analyzed user,
+// ignoring case with --merge-case-insensitive
+func (ga *GitAnalyzer) isUser(author string) bool {
+	if ga.config.MergeCase {
+		return strings.EqualFold(author, ga.config.Username)
+	}
+	return author == ga.config.Username
+}
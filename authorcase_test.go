@@ -0,0 +1,27 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMergeCaseInsensitive(t *testing.T) {
+	r := newTestRepo(t)
+	r.commit("JOHN DOE", map[string]string{"a.txt": lines("a", 1)})
+	r.commit("John Doe", map[string]string{"b.txt": lines("b", 2)})
+	r.commit("John Doe", map[string]string{"c.txt": lines("c", 3)})
+	r.commit("john doe", map[string]string{"d.txt": lines("d", 4)})
+
+	separate := r.analyze(nil)
+	if len(separate.Authors) != 3 {
+		t.Errorf("without merging: %d authors, want 3", len(separate.Authors))
+	}
+
+	merged := r.analyze(func(c *Config) { c.MergeCase = true })
+	if got, want := authorLines(merged), map[string]int{"John Doe": 10}; !reflect.DeepEqual(got, want) {
+		t.Errorf("merged author lines = %v, want %v", got, want)
+	}
+	if len(merged.Authors) == 1 && merged.Authors[0].FileCount != 4 {
+		t.Errorf("merged FileCount = %d, want 4", merged.Authors[0].FileCount)
+	}
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// authorFormatPresets are the named --author-format templates
+var authorFormatPresets = map[string]string{
+	"name":        "{name}",
+	"name-email":  "{name} <{email}>",
+	"email":       "{email}",
+	"name-handle": "{name} (@{handle})",
+}
+
+// authorPlaceholder matches a placeholder in an --author-format template
+var authorPlaceholder = regexp.MustCompile(`\{([a-z]+)\}`)
+
+// authorTemplate resolves an --author-format preset or template, checking
+// that it only uses {name}, {email} and {handle}
+func authorTemplate(format string) (string, error) {
+	if format == "" {
+		format = "name"
+	}
+	if template, ok := authorFormatPresets[format]; ok {
+		return template, nil
+	}
+
+	placeholders := authorPlaceholder.FindAllStringSubmatch(format, -1)
+	if len(placeholders) == 0 {
+		return "", fmt.Errorf("expected name, name-email, email, name-handle or a template using {name}, {email} or {handle}")
+	}
+	for _, m := range placeholders {
+		switch m[1] {
+		case "name", "email", "handle":
+		default:
+			return "", fmt.Errorf("unknown placeholder {%s} (expected {name}, {email} or {handle})", m[1])
+		}
+	}
+	return format, nil
+}
+
+// authorFormatUses reports whether the --author-format template uses a
+// placeholder, such as "email"
+func (ga *GitAnalyzer) authorFormatUses(placeholder string) bool {
+	template, err := authorTemplate(ga.config.AuthorFormat)
+	return err == nil && strings.Contains(template, "{"+placeholder+"}")
+}
+
+// primaryEmail picks the email shown for an author who committed with
+// several, the first in sorted order so every run shows the same one
+func primaryEmail(emails map[string]bool) string {
+	sorted := make([]string, 0, len(emails))
+	for email := range emails {
+		sorted = append(sorted, email)
+	}
+	sort.Strings(sorted)
+	if len(sorted) == 0 {
+		return ""
+	}
+	return sorted[0]
+}
+
+// formatAuthor renders an author with the --author-format template. An
+// author missing a value the template uses, such as an email, is shown by
+// name alone.
+func (ga *GitAnalyzer) formatAuthor(author AuthorStats) string {
+	template, err := authorTemplate(ga.config.AuthorFormat)
+	if err != nil {
+		return author.Name
+	}
+
+	values := map[string]string{"name": author.Name, "email": author.Email, "handle": author.GitHubHandle}
+	missing := false
+	label := authorPlaceholder.ReplaceAllStringFunc(template, func(placeholder string) string {
+		value := values[strings.Trim(placeholder, "{}")]
+		if value == "" {
+			missing = true
+		}
+		return value
+	})
+	if missing {
+		return author.Name
+	}
+	return label
+}
+
+// labelAuthors records the --author-format label of each author, including
+// those --limit cuts from the table but tiers and directories still name
+func (ga *GitAnalyzer) labelAuthors(authors []AuthorStats) {
+	if template, _ := authorTemplate(ga.config.AuthorFormat); template == "{name}" {
+		return
+	}
+	ga.authorLabels = make(map[string]string, len(authors))
+	for _, author := range authors {
+		ga.authorLabels[author.Name] = ga.formatAuthor(author)
+	}
+}
+
+// formatAuthors applies --author-format wherever a result names an author,
+// in every output format, JSON included, leaving the email and handle in
+// their own fields
+func (ga *GitAnalyzer) formatAuthors(result *AnalysisResult) {
+	if len(ga.authorLabels) == 0 {
+		return
+	}
+	renameAuthors(result, func(name string) string {
+		if label, ok := ga.authorLabels[name]; ok {
+			return label
+		}
+		return name
+	})
+}
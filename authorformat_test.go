@@ -0,0 +1,55 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestAuthorFormatEverywhere(t *testing.T) {
+	r := newTestRepo(t)
+	r.commit("Alice", map[string]string{"src/a.go": lines("a", 6), "shared.txt": lines("a", 2)})
+	r.commit("Bob", map[string]string{"docs/b.md": lines("b", 2), "shared.txt": lines("a", 2) + lines("b", 2)})
+
+	result := r.analyze(func(c *Config) {
+		c.AuthorFormat = "name-email"
+		c.MaxResults = 1
+		c.Tiers = true
+		c.RichSummary = true
+		c.DirDepth = 1
+		c.OrphanThreshold = 60
+	})
+
+	alice, bob := "Alice <alice@example.com>", "Bob <bob@example.com>"
+	if len(result.Authors) != 1 || result.Authors[0].Name != alice {
+		t.Errorf("authors = %+v, want only %s", result.Authors, alice)
+	}
+
+	// Bob is cut by --limit but still named in the tiers
+	var members []string
+	for _, tier := range result.Tiers {
+		members = append(members, tier.Members...)
+	}
+	slices.Sort(members)
+	if want := []string{alice, bob}; !slices.Equal(members, want) {
+		t.Errorf("tier members = %v, want %v", members, want)
+	}
+
+	if h := result.Highlights; h == nil || h.MostActive == nil || h.MostActive.Name != alice {
+		t.Errorf("highlights = %+v, want %s most active", result.Highlights, alice)
+	}
+	for _, dir := range result.Directories {
+		for _, owner := range dir.TopAuthors {
+			if owner.Name != alice && owner.Name != bob {
+				t.Errorf("directory %s: top author %q isn't formatted", dir.Path, owner.Name)
+			}
+		}
+	}
+	if len(result.OrphanedFiles) == 0 {
+		t.Fatal("no orphaned files")
+	}
+	for _, file := range result.OrphanedFiles {
+		if file.TopAuthor != alice && file.TopAuthor != bob {
+			t.Errorf("orphaned %s: top author %q isn't formatted", file.Path, file.TopAuthor)
+		}
+	}
+}
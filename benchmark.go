@@ -0,0 +1,190 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// benchmarkSeed fixes the benchmark's file sample so every configuration
+// blames the same files
+const benchmarkSeed = 1
+
+// benchmarkMargin is how much faster than the defaults a configuration must
+// be to be recommended, so timing noise doesn't suggest flags
+const benchmarkMargin = 0.9
+
+// benchmarkCase is a configuration tried by the benchmark
+type benchmarkCase struct {
+	concurrency int
+	lsFiles     bool
+	elapsed     time.Duration // fastest of the runs
+}
+
+// flags returns the command-line flags selecting the case's configuration,
+// leaving out the defaults
+func (c benchmarkCase) flags() string {
+	var flags []string
+	if c.concurrency != runtime.NumCPU()*2 {
+		flags = append(flags, "--concurrency "+strconv.Itoa(c.concurrency))
+	}
+	if c.lsFiles {
+		flags = append(flags, "--use-git-ls-files")
+	}
+	if len(flags) == 0 {
+		return "(defaults)"
+	}
+	return strings.Join(flags, " ")
+}
+
+// benchmarkCases returns the configurations to try: a few concurrency levels
+// around the default, each listing files by walking the directory and with
+// git ls-files
+func benchmarkCases() []benchmarkCase {
+	cpus := runtime.NumCPU()
+	levels := []int{cpus, cpus * 2, cpus * 4}
+	if cpus > 1 {
+		levels = append([]int{cpus / 2}, levels...)
+	}
+
+	var cases []benchmarkCase
+	for _, lsFiles := range []bool{false, true} {
+		for _, level := range levels {
+			cases = append(cases, benchmarkCase{concurrency: level, lsFiles: lsFiles})
+		}
+	}
+	return cases
+}
+
+// newBenchmarkCmd creates the command timing the analysis under several
+// configurations
+func newBenchmarkCmd() *cobra.Command {
+	var files, runs int
+
+	benchmarkCmd := &cobra.Command{
+		Use:   "benchmark [repo]",
+		Short: "Time the analysis under several configurations and suggest flags",
+		Long: `Time the analysis of a repository under several configurations and
+suggest the fastest flags.
+
+Each configuration lists the repository's files, by walking the directory or
+with git ls-files, and blames the same random sample of them at a different
+concurrency level. Sampling keeps the benchmark quick on large repositories;
+the timings compare configurations rather than predict a full run.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if files < 1 {
+				return errors.New("invalid --files: must be at least 1")
+			}
+			if runs < 1 {
+				return errors.New("invalid --runs: must be at least 1")
+			}
+
+			repo := "."
+			if len(args) > 0 {
+				repo = args[0]
+			}
+			absRepo, err := filepath.Abs(repo)
+			if err != nil {
+				return fmt.Errorf("invalid repository path: %w", err)
+			}
+
+			base := defaultConfig(absRepo)
+			base.Sample = files
+			base.Seed = benchmarkSeed
+
+			logged := base
+			logged.Quiet = false
+			ga := NewGitAnalyzer(logged)
+
+			ctx := cmd.Context()
+			if err := ga.checkGit(ctx); err != nil {
+				return err
+			}
+			if err := ga.validateDirectory(ctx); err != nil {
+				return err
+			}
+
+			// A warm-up run fills the filesystem cache so the first case
+			// isn't penalized
+			ga.logInfo("Benchmarking %s on a sample of %d files", absRepo, files)
+			warmup, err := NewGitAnalyzer(base).Analyze(ctx)
+			if err != nil {
+				return err
+			}
+
+			cases := benchmarkCases()
+			for i := range cases {
+				config := base
+				config.Concurrency = cases[i].concurrency
+				config.UseGitLsFiles = cases[i].lsFiles
+
+				for range runs {
+					start := time.Now()
+					if _, err := NewGitAnalyzer(config).Analyze(ctx); err != nil {
+						return err
+					}
+					if elapsed := time.Since(start); cases[i].elapsed == 0 || elapsed < cases[i].elapsed {
+						cases[i].elapsed = elapsed
+					}
+				}
+				ga.logDebug("%s: %v", cases[i].flags(), cases[i].elapsed)
+			}
+
+			fastest, defaults := 0, 0
+			for i, c := range cases {
+				if c.elapsed < cases[fastest].elapsed {
+					fastest = i
+				}
+				if c.flags() == "(defaults)" {
+					defaults = i
+				}
+			}
+			recommended := defaults
+			if float64(cases[fastest].elapsed) < float64(cases[defaults].elapsed)*benchmarkMargin {
+				recommended = fastest
+			}
+
+			w := cmd.OutOrStdout()
+			fmt.Fprintf(w, "\n%s\n", ga.styleHeader("Benchmark"))
+			table := ga.newTable(w)
+			table.Header([]string{"Concurrency", "File Listing", "Time", "Relative"})
+			for i, c := range cases {
+				listing := "walk"
+				if c.lsFiles {
+					listing = "git ls-files"
+				}
+				relative := fmt.Sprintf("%.2fx", c.elapsed.Seconds()/cases[fastest].elapsed.Seconds())
+				if i == fastest {
+					relative = "fastest"
+				}
+				table.Append([]string{
+					strconv.Itoa(c.concurrency),
+					listing,
+					c.elapsed.Round(time.Millisecond).String(),
+					relative,
+				})
+			}
+			table.Render()
+
+			blamed := warmup.TotalFiles
+			if warmup.Sampled {
+				blamed = warmup.SampleSize
+			}
+			fmt.Fprintf(w, "\nFiles blamed per run: %d of %d\n", blamed, warmup.TotalFiles)
+			fmt.Fprintf(w, "Recommended flags: %s\n", cases[recommended].flags())
+			return nil
+		},
+	}
+
+	benchmarkCmd.Flags().IntVar(&files, "files", 200, "Number of files to blame per run")
+	benchmarkCmd.Flags().IntVar(&runs, "runs", 1, "Runs per configuration; the fastest counts")
+
+	return benchmarkCmd
+}
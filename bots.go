@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// BotsLabel is the entry bot accounts are merged into with --merge-bots
+const BotsLabel = "Bots"
+
+// defaultBotPatterns match the naming conventions of common bot accounts
+var defaultBotPatterns = []string{
+	`(?i)\[bot\]$`,
+	`(?i)^(dependabot|renovate|github-actions|greenkeeper|snyk-bot|mergify|imgbot|pre-commit-ci)\b`,
+	`(?i)[-_ ]bot$`,
+}
+
+// compileBotPatterns compiles bot detection patterns, falling back to the
+// defaults when none are configured
+func compileBotPatterns(patterns []string) ([]*regexp.Regexp, error) {
+	if len(patterns) == 0 {
+		patterns = defaultBotPatterns
+	}
+
+	compiled := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("pattern %q: %w", pattern, err)
+		}
+		compiled = append(compiled, re)
+	}
+	return compiled, nil
+}
+
+// isBot reports whether an author name looks like a bot account
+func (ga *GitAnalyzer) isBot(author string) bool {
+	for _, re := range ga.botPatterns {
+		if re.MatchString(author) {
+			return true
+		}
+	}
+	return false
+}
+
+// tallyName returns the name lines by an author are counted under
+func (ga *GitAnalyzer) tallyName(author string) string {
+	if ga.config.MergeBots && ga.isBot(author) {
+		return BotsLabel
+	}
+	return ga.foldCase(author)
+}
@@ -0,0 +1,276 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// branch is a local or remote-tracking branch and the commit it points to
+type branch struct {
+	Name   string
+	Commit string
+}
+
+// originLine identifies a line by the commit and position that introduced
+// it, so the same line blamed on several branches is counted once
+type originLine struct {
+	commit string
+	path   string
+	line   int
+}
+
+// listBranches enumerates local and remote-tracking branches. Branches
+// pointing to the same commit are listed once, and symbolic refs such as
+// origin/HEAD are skipped.
+func (ga *GitAnalyzer) listBranches(ctx context.Context) ([]branch, error) {
+	output, err := ga.gitCommand(ctx, "for-each-ref",
+		"--format=%(objectname) %(symref) %(refname:short)",
+		"refs/heads", "refs/remotes").Output()
+	if err != nil {
+		return nil, fmt.Errorf("git for-each-ref: %w", err)
+	}
+
+	var branches []branch
+	seen := make(map[string]bool)
+	for line := range strings.SplitSeq(strings.TrimSpace(string(output)), "\n") {
+		commit, rest, ok := strings.Cut(line, " ")
+		if !ok {
+			continue
+		}
+		symref, name, _ := strings.Cut(rest, " ")
+		if symref != "" || seen[commit] {
+			continue
+		}
+		seen[commit] = true
+		branches = append(branches, branch{Name: name, Commit: commit})
+	}
+
+	return branches, nil
+}
+
+// branchFiles lists the analyzable files in a branch's tree
+func (ga *GitAnalyzer) branchFiles(ctx context.Context, b branch) ([]string, error) {
+	output, err := ga.gitCommand(ctx, "ls-tree", "-r", "-z", "-l", b.Commit).Output()
+	if err != nil {
+		return nil, fmt.Errorf("git ls-tree %s: %w", b.Name, err)
+	}
+
+	var files []string
+	for entry := range strings.SplitSeq(string(output), "\x00") {
+		// "<mode> <type> <object> <size>\t<path>"
+		meta, path, ok := strings.Cut(entry, "\t")
+		if !ok || !strings.HasPrefix(meta, "100") {
+			continue // only regular files, not symlinks or submodules
+		}
+		if ga.shouldExcludeFile(filepath.FromSlash(path)) {
+			continue
+		}
+		if ga.config.MaxFileSize > 0 {
+			fields := strings.Fields(meta)
+			if size, err := strconv.ParseInt(fields[len(fields)-1], 10, 64); err == nil && size > ga.config.MaxFileSize {
+				continue
+			}
+		}
+		files = append(files, path)
+	}
+
+	return files, nil
+}
+
+// blameAt runs git blame on a file as of the given commit
+func (ga *GitAnalyzer) blameAt(ctx context.Context, commit, path string) ([]blameLine, error) {
+	args := ga.blameArgs()
+	if ga.config.DateSince != "" {
+		args = append(args, "--since="+ga.config.DateSince)
+	}
+	if ga.config.DateUntil != "" {
+		args = append(args, "--until="+ga.config.DateUntil)
+	}
+	args = append(args, commit, "--", path)
+
+	output, err := ga.gitCommand(ctx, args...).Output()
+	if err != nil {
+		return nil, err
+	}
+
+	role := ga.config.Credit
+	if role == "" {
+		role = CreditAuthor
+	}
+	return parsePorcelain(output, role, ga.timeBasis()), nil
+}
+
+// analyzeAllBranches blames every file on every branch and counts each line
+// once, identified by the commit, file and line number that introduced it.
+// Lines that only survive on unmerged branches are credited too.
+func (ga *GitAnalyzer) analyzeAllBranches(ctx context.Context) (*AnalysisResult, error) {
+	startTime := time.Now()
+
+	branches, err := ga.listBranches(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(branches) == 0 {
+		return nil, fmt.Errorf("no branches found")
+	}
+
+	if !ga.config.Quiet {
+		ga.logWarn("Blaming every file on %d branches; this takes roughly %d times as long as analyzing HEAD",
+			len(branches), len(branches))
+	}
+
+	concurrency := ga.config.Concurrency
+	if concurrency <= 0 {
+		concurrency = runtime.NumCPU() * 2
+	}
+
+	var mu sync.Mutex
+	seen := make(map[originLine]bool)
+	authorCounts := make(map[string]int)
+	authorFiles := make(map[string]map[string]bool)
+	authorSpans := make(map[string]authorSpan)
+	paths := make(map[string]bool)
+	totalLines := 0
+	excludedLines := 0
+
+	names := make([]string, 0, len(branches))
+	for i, b := range branches {
+		names = append(names, b.Name)
+
+		files, err := ga.branchFiles(ctx, b)
+		if err != nil {
+			return nil, err
+		}
+
+		if !ga.config.Quiet {
+			ga.logInfo("[%d/%d] Blaming %s files on %s", i+1, len(branches), ga.formatNumber(len(files)), b.Name)
+		}
+
+		g, gctx := errgroup.WithContext(ctx)
+		g.SetLimit(concurrency)
+
+		for _, path := range files {
+			g.Go(func() error {
+				lines, err := ga.blameAt(gctx, b.Commit, path)
+				if err != nil {
+					if gctx.Err() != nil {
+						return gctx.Err()
+					}
+					ga.logDebug("Error processing %s on %s: %v", path, b.Name, err)
+					return nil
+				}
+
+				mu.Lock()
+				defer mu.Unlock()
+
+				paths[path] = true
+				code := ga.newCodeLineFilter(path)
+				for _, line := range lines {
+					if code != nil && !code.isCode(line.Content) {
+						continue
+					}
+					line.Author = ga.knownName(line.Author)
+					key := originLine{line.Commit, line.OrigPath, line.OrigLine}
+					if seen[key] {
+						continue
+					}
+					seen[key] = true
+
+					if ga.shouldExcludeAuthor(line.Author) {
+						excludedLines++
+						continue
+					}
+
+					author := ga.tallyName(line.Author)
+					authorCounts[author]++
+					totalLines++
+					if authorFiles[author] == nil {
+						authorFiles[author] = make(map[string]bool)
+					}
+					authorFiles[author][path] = true
+					if line.Time > 0 {
+						authorSpans[author] = authorSpans[author].extend(line.Time)
+					}
+				}
+				return nil
+			})
+		}
+
+		if err := g.Wait(); err != nil {
+			return nil, err
+		}
+	}
+
+	denominator := float64(totalLines)
+	if ga.config.NoTrimTotal {
+		denominator = float64(totalLines + excludedLines)
+	}
+
+	authors := make([]AuthorStats, 0, len(authorCounts))
+	for name, count := range authorCounts {
+		span := authorSpans[name]
+		active := ga.config.ActiveSince.IsZero() || span.Last >= ga.config.ActiveSince.Unix()
+		if count < ga.config.MinLines || len(authorFiles[name]) < ga.config.MinFiles || !active {
+			continue
+		}
+		stats := AuthorStats{
+			Name:       name,
+			LineCount:  count,
+			FileCount:  len(authorFiles[name]),
+			Percentage: float64(count) / denominator * 100,
+		}
+		if span.Last > 0 {
+			stats.FirstCommit = formatCommitDate(span.First)
+			stats.LastCommit = formatCommitDate(span.Last)
+		}
+		authors = append(authors, stats)
+	}
+
+	if ga.config.Score {
+		ga.assignScores(authors, authorSpans, time.Now())
+	}
+
+	assignPercentiles(authors)
+	ga.assignRanks(authors)
+	ga.sortAuthors(authors)
+
+	var tiers []TierStats
+	if ga.config.Tiers {
+		tiers = ga.assignTiers(authors)
+	}
+	metrics := ga.ownershipMetrics(authors)
+
+	var others *OthersStats
+	if ga.config.MaxResults > 0 && len(authors) > ga.config.MaxResults {
+		if !ga.config.NoOthers {
+			others = summarizeOthers(authors[ga.config.MaxResults:], authorFiles)
+		}
+		authors = authors[:ga.config.MaxResults]
+	}
+
+	sort.Strings(names)
+
+	return &AnalysisResult{
+		Authors:         authors,
+		TotalLines:      totalLines,
+		FilesProcessed:  len(paths),
+		TotalFiles:      len(paths),
+		ProcessingTime:  time.Since(startTime),
+		Repository:      ga.config.Directory,
+		GeneratedAt:     time.Now(),
+		UnfilteredLines: ga.untrimmedTotal(totalLines + excludedLines),
+		Others:          others,
+		Tiers:           tiers,
+		Metrics:         metrics,
+		Branches:        names,
+	}, nil
+}
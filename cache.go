@@ -0,0 +1,71 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// cacheRoot returns the directory gala keeps its state in: the user cache
+// directory (e.g. ~/.cache/gala on Linux, ~/Library/Caches/gala on macOS),
+// or the temporary directory when no user cache directory is available
+func cacheRoot() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "gala")
+}
+
+// repoCacheDir returns the cache directory for the analyzed repository, keyed
+// by its path. With --read-only, a cache directory that resolves inside the
+// repository is refused rather than written to.
+func (ga *GitAnalyzer) repoCacheDir() (string, error) {
+	sum := sha256.Sum256([]byte(ga.config.Directory))
+	dir := filepath.Join(cacheRoot(), hex.EncodeToString(sum[:8]))
+
+	if ga.config.ReadOnly && isWithinDir(dir, ga.config.Directory) {
+		return "", fmt.Errorf("cache directory %s is inside the analyzed repository", dir)
+	}
+	return dir, nil
+}
+
+// isWithinDir reports whether path is dir or lies below it
+func isWithinDir(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return false
+	}
+	return rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)))
+}
+
+// newCacheCmd creates the cache management command
+func newCacheCmd() *cobra.Command {
+	cacheCmd := &cobra.Command{
+		Use:   "cache",
+		Short: "Manage gala's cache directory",
+		Args:  cobra.NoArgs,
+	}
+
+	clearCmd := &cobra.Command{
+		Use:   "clear",
+		Short: "Remove all cached state, including checkpoints of interrupted runs",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			root := cacheRoot()
+			if err := os.RemoveAll(root); err != nil {
+				return fmt.Errorf("failed to clear cache: %w", err)
+			}
+			fmt.Printf("Cleared %s\n", root)
+			return nil
+		},
+	}
+
+	cacheCmd.AddCommand(clearCmd)
+	return cacheCmd
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// dedupeCaseCollisions finds files whose paths differ only in case, such as
+// README.md and readme.md. On a case-insensitive filesystem both paths name
+// the same file, which would be blamed twice, so all but the first are
+// dropped. On a case-sensitive filesystem they are distinct files and kept,
+// but they collide when the repository is checked out on macOS or Windows,
+// so either way they're reported.
+func (ga *GitAnalyzer) dedupeCaseCollisions(files []string) []string {
+	first := make(map[string]string, len(files)) // by lowercased path
+	kept := make([]string, 0, len(files))
+	collisions, dropped := 0, 0
+
+	for _, file := range files {
+		key := strings.ToLower(file)
+		other, ok := first[key]
+		if !ok {
+			first[key] = file
+			kept = append(kept, file)
+			continue
+		}
+
+		collisions++
+		rel, _ := filepath.Rel(ga.config.Directory, file)
+		otherRel, _ := filepath.Rel(ga.config.Directory, other)
+		if sameFile(file, other) {
+			dropped++
+			ga.logDebug("Skipping %s: the same file as %s on this case-insensitive filesystem", rel, otherRel)
+			continue
+		}
+		ga.logDebug("Paths differ only in case: %s and %s", otherRel, rel)
+		kept = append(kept, file)
+	}
+
+	switch {
+	case dropped > 0:
+		ga.logWarn("Skipped %d files whose paths differ only in case from another path to the same file; run with --verbose to list them", dropped)
+	case collisions > 0:
+		ga.logWarn("%d files have paths differing only in case from another file, which collide on case-insensitive filesystems; run with --verbose to list them", collisions)
+	}
+	return kept
+}
+
+// sameFile reports whether two paths lead to the same file
+func sameFile(a, b string) bool {
+	infoA, err := os.Stat(a)
+	if err != nil {
+		return false
+	}
+	infoB, err := os.Stat(b)
+	if err != nil {
+		return false
+	}
+	return os.SameFile(infoA, infoB)
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestDedupeCaseCollisions(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(name+"\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	upper := write("README.md")
+	lower := filepath.Join(dir, "readme.md")
+	if _, err := os.Stat(lower); err == nil {
+		t.Skip("case-insensitive filesystem")
+	}
+	write("readme.md")
+	other := write("main.go")
+
+	ga := NewGitAnalyzer(defaultConfig(dir))
+
+	// Distinct files on a case-sensitive filesystem are both kept
+	files := []string{upper, lower, other}
+	if got := ga.dedupeCaseCollisions(files); !reflect.DeepEqual(got, files) {
+		t.Errorf("distinct files: got %v, want %v", got, files)
+	}
+
+	// Two names for one file, as on a case-insensitive filesystem, are
+	// blamed once; a hard link stands in for the second name
+	mixed := filepath.Join(dir, "Readme.md")
+	if err := os.Link(upper, mixed); err != nil {
+		t.Skipf("hard links unsupported: %v", err)
+	}
+	want := []string{upper, lower, other}
+	if got := ga.dedupeCaseCollisions([]string{upper, lower, mixed, other}); !reflect.DeepEqual(got, want) {
+		t.Errorf("same file: got %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// checkpointFlushInterval bounds how much completed work an interrupted run can lose
+const checkpointFlushInterval = time.Second
+
+// checkpointHeader is the first line of a checkpoint file. Results are only
+// reused when the fingerprint matches the current run's configuration.
+type checkpointHeader struct {
+	Repository  string    `json:"repository"`
+	Fingerprint string    `json:"fingerprint"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// checkpointPattern names the checkpoint files in a repository's cache
+// directory. Each run writes its own, so concurrent runs on the same
+// repository never share one.
+const checkpointPattern = "checkpoint-*.jsonl"
+
+// checkpoint appends completed blame results to a JSON lines file so that an
+// interrupted run can be resumed with --resume
+type checkpoint struct {
+	path       string
+	supersedes string // checkpoint this run resumed from, deleted once replaced
+	file       *os.File
+	writer     *bufio.Writer
+	encoder    *json.Encoder
+	lastFlush  time.Time
+}
+
+// checkpointFingerprint identifies the options that influence per-file blame
+// results. Options that change what runGitBlame returns must be added here.
+func (ga *GitAnalyzer) checkpointFingerprint() string {
+	data, _ := json.Marshal(struct {
+		Since, Until     string
+		Exclude, Include []string
+		Dedupe           bool
+		Credit           CreditMode
+		ExcludeBots      bool
+		BotPatterns      []string
+		IgnoreRevs       []string
+		Commits          bool
+		Unknown          string
+		ExcludeUnknown   bool
+		TimeBasis        TimeBasis
+		Times            bool
+		CountMode        CountMode
+		CreditMoves      MoveCredit
+		CommitSet        string
+		Window           *timeWindow
+	}{
+		ga.config.DateSince, ga.config.DateUntil,
+		ga.config.ExcludeAuthor, ga.config.IncludeAuthor,
+		ga.config.DedupeLines, ga.config.Credit,
+		ga.config.ExcludeBots, ga.config.BotPatterns,
+		ga.ignoreRevsArgs(), ga.config.ShowCommits,
+		ga.config.UnknownLabel, ga.config.ExcludeUnknown,
+		ga.timeBasis(), ga.weighting(WeightRecencyDecay),
+		ga.config.CountMode, ga.config.CreditMoves,
+		ga.commitSetDigest(), ga.window,
+	})
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// loadCheckpoint reads the results of the latest interrupted run with the
+// current options, keyed by file path, and returns the checkpoint's path.
+// Checkpoints created with other options are ignored.
+func (ga *GitAnalyzer) loadCheckpoint() (map[string]BlameResult, string, error) {
+	dir, err := ga.repoCacheDir()
+	if err != nil {
+		return nil, "", err
+	}
+	paths, err := filepath.Glob(filepath.Join(dir, checkpointPattern))
+	if err != nil {
+		return nil, "", err
+	}
+
+	var latest string
+	var latestHeader checkpointHeader
+	for _, path := range paths {
+		header, ok := readCheckpointHeader(path)
+		if !ok || header.Repository != ga.config.Directory || header.Fingerprint != ga.checkpointFingerprint() {
+			ga.logDebug("Ignoring checkpoint created with different options: %s", path)
+			continue
+		}
+		if latest == "" || header.CreatedAt.After(latestHeader.CreatedAt) {
+			latest, latestHeader = path, header
+		}
+	}
+	if latest == "" {
+		return nil, "", nil
+	}
+
+	file, err := os.Open(latest)
+	if err != nil {
+		return nil, "", err
+	}
+	defer file.Close()
+
+	decoder := json.NewDecoder(bufio.NewReader(file))
+	var header checkpointHeader
+	if err := decoder.Decode(&header); err != nil {
+		return nil, "", nil
+	}
+
+	results := make(map[string]BlameResult)
+	for {
+		var result BlameResult
+		if err := decoder.Decode(&result); err != nil {
+			// A truncated last line is expected after a hard kill
+			break
+		}
+		results[result.FilePath] = result
+	}
+
+	return results, latest, nil
+}
+
+// readCheckpointHeader reads the header line of a checkpoint file
+func readCheckpointHeader(path string) (checkpointHeader, bool) {
+	var header checkpointHeader
+	file, err := os.Open(path)
+	if err != nil {
+		return header, false
+	}
+	defer file.Close()
+	err = json.NewDecoder(bufio.NewReader(file)).Decode(&header)
+	return header, err == nil
+}
+
+// createCheckpoint starts a fresh checkpoint file for this run. supersedes
+// is the checkpoint the run resumed from, if any: its results are recorded
+// again in the new one, so it is deleted once the new one is complete.
+func (ga *GitAnalyzer) createCheckpoint(supersedes string) (*checkpoint, error) {
+	dir, err := ga.repoCacheDir()
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+
+	// CreateTemp opens a new file exclusively, with mode 0600
+	file, err := os.CreateTemp(dir, checkpointPattern)
+	if err != nil {
+		return nil, err
+	}
+
+	cp := &checkpoint{
+		path:       file.Name(),
+		supersedes: supersedes,
+		file:       file,
+		writer:     bufio.NewWriter(file),
+		lastFlush:  time.Now(),
+	}
+	cp.encoder = json.NewEncoder(cp.writer)
+
+	header := checkpointHeader{
+		Repository:  ga.config.Directory,
+		Fingerprint: ga.checkpointFingerprint(),
+		CreatedAt:   time.Now(),
+	}
+	if err := cp.encoder.Encode(header); err != nil {
+		file.Close()
+		os.Remove(cp.path)
+		return nil, err
+	}
+
+	return cp, nil
+}
+
+// record appends a completed result, flushing to disk periodically
+func (cp *checkpoint) record(result BlameResult) error {
+	if err := cp.encoder.Encode(result); err != nil {
+		return err
+	}
+	if time.Since(cp.lastFlush) >= checkpointFlushInterval {
+		cp.lastFlush = time.Now()
+		return cp.writer.Flush()
+	}
+	return nil
+}
+
+// close flushes pending results and keeps the checkpoint for a later
+// --resume, in place of the one this run resumed from
+func (cp *checkpoint) close() error {
+	flushErr := cp.writer.Flush()
+	if err := cp.file.Close(); err != nil {
+		return err
+	}
+	if flushErr != nil {
+		return flushErr
+	}
+	cp.removeSuperseded()
+	return nil
+}
+
+// remove discards the checkpoint after a successful run, along with the one
+// it resumed from
+func (cp *checkpoint) remove() error {
+	cp.file.Close()
+	cp.removeSuperseded()
+	return os.Remove(cp.path)
+}
+
+// removeSuperseded deletes the checkpoint this run resumed from
+func (cp *checkpoint) removeSuperseded() {
+	if cp.supersedes != "" {
+		os.Remove(cp.supersedes)
+	}
+}
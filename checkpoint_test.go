@@ -0,0 +1,64 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestCheckpointsArePerRun(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	config := defaultConfig(t.TempDir())
+	config.NoCheckpoint = false
+	ga := NewGitAnalyzer(config)
+
+	// Two concurrent runs write separate checkpoints
+	first, err := ga.createCheckpoint("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := ga.createCheckpoint("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.path == second.path {
+		t.Fatalf("both runs checkpoint to %s", first.path)
+	}
+	if err := first.record(BlameResult{FilePath: "a.go"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := first.close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Finishing the other run doesn't discard the interrupted one's progress
+	if err := second.remove(); err != nil {
+		t.Fatal(err)
+	}
+	results, path, err := ga.loadCheckpoint()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path != first.path || len(results) != 1 {
+		t.Fatalf("loadCheckpoint = %d results from %q, want 1 from %q", len(results), path, first.path)
+	}
+
+	// A run with other options doesn't resume from it
+	other := config
+	other.DateSince = "2020-01-01"
+	if results, _, _ := NewGitAnalyzer(other).loadCheckpoint(); results != nil {
+		t.Errorf("checkpoint with different options loaded %d results", len(results))
+	}
+
+	// The resumed checkpoint is replaced once the resuming run completes
+	resumed, err := ga.createCheckpoint(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := resumed.remove(); err != nil {
+		t.Fatal(err)
+	}
+	dir, _ := ga.repoCacheDir()
+	if left, _ := filepath.Glob(filepath.Join(dir, checkpointPattern)); len(left) != 0 {
+		t.Errorf("checkpoints left after a completed run: %v", left)
+	}
+}
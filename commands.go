@@ -0,0 +1,82 @@
+package main
+
+import (
+	"strings"
+	"sync"
+)
+
+// GitCommand is a git invocation recorded with --record-commands. Arguments
+// that vary per file are replaced by {file} and {range} placeholders, and
+// identical invocations are counted instead of repeated.
+type GitCommand struct {
+	Args  []string `json:"args"`
+	Count int      `json:"count"`
+}
+
+// commandRecorder collects the git commands an analysis runs
+type commandRecorder struct {
+	mu       sync.Mutex
+	commands []GitCommand
+	index    map[string]int
+}
+
+func newCommandRecorder() *commandRecorder {
+	return &commandRecorder{index: make(map[string]int)}
+}
+
+// record adds an invocation, summarized as its template
+func (r *commandRecorder) record(argv []string) {
+	template := commandTemplate(argv)
+	key := strings.Join(template, "\x00")
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if i, ok := r.index[key]; ok {
+		r.commands[i].Count++
+		return
+	}
+	r.index[key] = len(r.commands)
+	r.commands = append(r.commands, GitCommand{Args: template, Count: 1})
+}
+
+// list returns the recorded commands in the order first seen
+func (r *commandRecorder) list() []GitCommand {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]GitCommand(nil), r.commands...)
+}
+
+// commandTemplate replaces the paths after "--" with {file} and the values of
+// -L with {range}, so per-file invocations share a template
+func commandTemplate(argv []string) []string {
+	template := make([]string, len(argv))
+	copy(template, argv)
+
+	for i := 0; i < len(template); i++ {
+		switch template[i] {
+		case "-L":
+			if i+1 < len(template) {
+				template[i+1] = "{range}"
+				i++
+			}
+		case "--":
+			if i+1 < len(template) {
+				template = append(template[:i+1], "{file}")
+				return template
+			}
+		}
+	}
+	return template
+}
+
+// logCommands prints the recorded commands as diagnostics
+func (ga *GitAnalyzer) logCommands(commands []GitCommand) {
+	for _, command := range commands {
+		if command.Count > 1 {
+			ga.logInfo("Ran %s (%d times)", strings.Join(command.Args, " "), command.Count)
+		} else {
+			ga.logInfo("Ran %s", strings.Join(command.Args, " "))
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// CountMode selects which lines are counted
+type CountMode string
+
+const (
+	CountPhysical CountMode = "physical" // every line
+	CountCode     CountMode = "code"     // lines other than blank lines and full-line comments
+)
+
+// commentSyntax describes a language's comments
+type commentSyntax struct {
+	line       []string // prefixes of comments running to the end of the line
+	blockStart string
+	blockEnd   string
+}
+
+var (
+	cStyle     = commentSyntax{line: []string{"//"}, blockStart: "/*", blockEnd: "*/"}
+	hashStyle  = commentSyntax{line: []string{"#"}}
+	dashStyle  = commentSyntax{line: []string{"--"}}
+	semiStyle  = commentSyntax{line: []string{";"}}
+	xmlStyle   = commentSyntax{blockStart: "<!--", blockEnd: "-->"}
+	cssStyle   = commentSyntax{blockStart: "/*", blockEnd: "*/"}
+	noComments = commentSyntax{}
+)
+
+// commentSyntaxByLanguage maps the languages detectLanguage reports to their
+// comment syntax. Docstrings and other string-based comments are counted as
+// code.
+var commentSyntaxByLanguage = map[string]commentSyntax{
+	"Go":              cStyle,
+	"JavaScript":      cStyle,
+	"TypeScript":      cStyle,
+	"TSX":             cStyle,
+	"Rust":            cStyle,
+	"C":               cStyle,
+	"C++":             cStyle,
+	"Objective-C":     cStyle,
+	"Objective-C++":   cStyle,
+	"Java":            cStyle,
+	"Kotlin":          cStyle,
+	"Scala":           cStyle,
+	"Groovy":          cStyle,
+	"C#":              cStyle,
+	"F#":              {line: []string{"//"}, blockStart: "(*", blockEnd: "*)"},
+	"Swift":           cStyle,
+	"PHP":             {line: []string{"//", "#"}, blockStart: "/*", blockEnd: "*/"},
+	"Dart":            cStyle,
+	"Zig":             {line: []string{"//"}},
+	"Protocol Buffer": cStyle,
+	"SCSS":            cStyle,
+	"Sass":            cStyle,
+	"Less":            cStyle,
+	"CSS":             cssStyle,
+	"Python":          hashStyle,
+	"Ruby":            {line: []string{"#"}, blockStart: "=begin", blockEnd: "=end"},
+	"Perl":            hashStyle,
+	"Shell":           hashStyle,
+	"fish":            hashStyle,
+	"PowerShell":      {line: []string{"#"}, blockStart: "<#", blockEnd: "#>"},
+	"R":               hashStyle,
+	"Elixir":          hashStyle,
+	"Julia":           {line: []string{"#"}, blockStart: "#=", blockEnd: "=#"},
+	"Nim":             hashStyle,
+	"YAML":            hashStyle,
+	"TOML":            hashStyle,
+	"Makefile":        hashStyle,
+	"CMake":           hashStyle,
+	"Dockerfile":      hashStyle,
+	"Starlark":        hashStyle,
+	"Nix":             {line: []string{"#"}, blockStart: "/*", blockEnd: "*/"},
+	"HCL":             {line: []string{"#", "//"}, blockStart: "/*", blockEnd: "*/"},
+	"Lua":             {line: []string{"--"}, blockStart: "--[[", blockEnd: "]]"},
+	"SQL":             {line: []string{"--"}, blockStart: "/*", blockEnd: "*/"},
+	"Haskell":         {line: []string{"--"}, blockStart: "{-", blockEnd: "-}"},
+	"OCaml":           {blockStart: "(*", blockEnd: "*)"},
+	"Erlang":          {line: []string{"%"}},
+	"Clojure":         semiStyle,
+	"Emacs Lisp":      semiStyle,
+	"Vim Script":      {line: []string{"\""}},
+	"HTML":            xmlStyle,
+	"XML":             xmlStyle,
+	"Vue":             xmlStyle,
+	"Svelte":          xmlStyle,
+	"Markdown":        noComments,
+	"JSON":            noComments,
+}
+
+// codeLineFilter decides line by line whether a file's lines are code. It
+// tracks block comments, so lines must be given in file order.
+type codeLineFilter struct {
+	syntax  commentSyntax
+	inBlock bool
+}
+
+// newCodeLineFilter returns a filter for the file's language, or nil in
+// physical count mode. Files of unknown languages only skip blank lines.
+func (ga *GitAnalyzer) newCodeLineFilter(path string) *codeLineFilter {
+	if ga.config.CountMode != CountCode {
+		return nil
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(ga.config.Directory, path)
+	}
+	return &codeLineFilter{syntax: commentSyntaxByLanguage[detectLanguage(path)]}
+}
+
+// isCode reports whether the line is neither blank nor entirely comment
+func (f *codeLineFilter) isCode(content string) bool {
+	line := strings.TrimSpace(content)
+	s := f.syntax
+
+	if f.inBlock {
+		_, after, closed := strings.Cut(line, s.blockEnd)
+		if !closed {
+			return false
+		}
+		f.inBlock = false
+		line = strings.TrimSpace(after)
+	}
+
+	for line != "" {
+		if s.blockStart == "" || !strings.HasPrefix(line, s.blockStart) {
+			break
+		}
+		_, after, closed := strings.Cut(line[len(s.blockStart):], s.blockEnd)
+		if !closed {
+			f.inBlock = true
+			return false
+		}
+		line = strings.TrimSpace(after)
+	}
+
+	if line == "" {
+		return false
+	}
+	for _, prefix := range s.line {
+		if strings.HasPrefix(line, prefix) {
+			return false
+		}
+	}
+	return true
+}
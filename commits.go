@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// commitsFromStdin is the --commits-from value that reads stdin
+const commitsFromStdin = "-"
+
+// readCommitList reads the revisions listed in a --commits-from file: the
+// first field of each line, so `git log --oneline` output can be used as is.
+// Blank lines and lines starting with "#" are ignored.
+func readCommitList(r io.Reader) ([]string, error) {
+	var revs []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		revs = append(revs, fields[0])
+	}
+	return revs, scanner.Err()
+}
+
+// loadCommitSet resolves the --commits-from revisions, which may be
+// abbreviated, to full commit hashes with a single git cat-file call
+func (ga *GitAnalyzer) loadCommitSet(ctx context.Context) error {
+	var r io.Reader = os.Stdin
+	if ga.config.CommitsFrom != commitsFromStdin {
+		file, err := os.Open(ga.config.CommitsFrom)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		r = file
+	}
+
+	revs, err := readCommitList(r)
+	if err != nil {
+		return err
+	}
+	if len(revs) == 0 {
+		return fmt.Errorf("no commits listed in %s", ga.config.CommitsFrom)
+	}
+
+	hashes, err := ga.resolveCommits(ctx, revs)
+	if err != nil {
+		return err
+	}
+	ga.commitSet = make(map[string]bool, len(revs))
+	for i, hash := range hashes {
+		if hash == "" {
+			return fmt.Errorf("%q is not a commit in this repository", revs[i])
+		}
+		ga.commitSet[hash] = true
+	}
+
+	ga.logDebug("Counting only lines from %d commits", len(ga.commitSet))
+	return nil
+}
+
+// resolveCommits resolves revisions, which may be abbreviated, to full
+// commit hashes with a single git cat-file call. The hashes are in the order
+// of revs, with "" for each revision that doesn't name a commit.
+func (ga *GitAnalyzer) resolveCommits(ctx context.Context, revs []string) ([]string, error) {
+	var input bytes.Buffer
+	for _, rev := range revs {
+		input.WriteString(rev + "^{commit}\n")
+	}
+	cmd := ga.gitCommand(ctx, "cat-file", "--batch-check=%(objectname)")
+	cmd.Stdin = &input
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git cat-file: %w", err)
+	}
+
+	// One line per revision, in input order: the hash, or "<rev> missing"
+	// (or "ambiguous") when it doesn't name a commit
+	hashes := make([]string, len(revs))
+	lines := strings.Split(strings.TrimSuffix(string(output), "\n"), "\n")
+	for i, line := range lines {
+		if i < len(hashes) && !strings.HasSuffix(line, " missing") && !strings.HasSuffix(line, " ambiguous") {
+			hashes[i] = line
+		}
+	}
+	return hashes, nil
+}
+
+// commitSetDigest identifies the --commits-from set for checkpoints
+func (ga *GitAnalyzer) commitSetDigest() string {
+	if ga.commitSet == nil {
+		return ""
+	}
+	hashes := make([]string, 0, len(ga.commitSet))
+	for hash := range ga.commitSet {
+		hashes = append(hashes, hash)
+	}
+	sort.Strings(hashes)
+	sum := sha256.Sum256([]byte(strings.Join(hashes, "\n")))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// configNames are the config file names looked for in each search
+// directory, in order. The extension selects the format.
+var configNames = []string{"gala.yaml", "gala.yml", "gala.json", "gala.toml"}
+
+// RCFileName is a config file without an extension, read as YAML (which
+// also accepts JSON)
+const RCFileName = ".galarc"
+
+// PyprojectFileName holds gala's settings in a [tool.gala] table
+const PyprojectFileName = "pyproject.toml"
+
+// pyprojectSection is the table in pyproject.toml that holds gala's settings
+const pyprojectSection = "tool.gala"
+
+// configDirPaths lists the config files looked for in a directory, in the
+// order they're tried
+func configDirPaths(dir string) []string {
+	paths := make([]string, 0, len(configNames)+2)
+	for _, name := range configNames {
+		paths = append(paths, filepath.Join(dir, name))
+	}
+	return append(paths, filepath.Join(dir, RCFileName), filepath.Join(dir, PyprojectFileName))
+}
+
+// userConfigPaths lists the user and system config files, in the order
+// they're tried
+func userConfigPaths() []string {
+	var paths []string
+	if home, err := os.UserHomeDir(); err == nil {
+		for _, name := range configNames {
+			paths = append(paths, filepath.Join(home, ".config", "gala", name))
+		}
+		paths = append(paths, filepath.Join(home, RCFileName))
+	}
+
+	for _, name := range configNames {
+		paths = append(paths, filepath.Join("/etc", "gala", name))
+	}
+
+	return paths
+}
+
+// workingTreeRoot returns the top level of the git working tree containing
+// the analyzed directory, or "" when it isn't in one. Only the command line
+// and environment choose the git it runs, since the config file isn't read
+// yet.
+func workingTreeRoot(config Config) string {
+	output, err := newGitCommand(context.Background(), config, "rev-parse", "--show-toplevel").Output()
+	if err != nil {
+		return ""
+	}
+	return filepath.FromSlash(strings.TrimSpace(string(output)))
+}
+
+// findConfig reads the first config file found among paths and returns its
+// path, or "" when there is none
+func findConfig(paths []string) (string, error) {
+	for _, path := range paths {
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+		found, err := readConfig(path)
+		if err != nil {
+			return "", fmt.Errorf("reading config file %s: %w", path, err)
+		}
+		if found {
+			return path, nil
+		}
+	}
+	return "", nil
+}
+
+// readConfig reads a config file into viper. A pyproject.toml only counts
+// when it has a [tool.gala] table; found reports whether it did.
+func readConfig(path string) (found bool, err error) {
+	base := filepath.Base(path)
+
+	if base == PyprojectFileName {
+		project := viper.New()
+		project.SetConfigFile(path)
+		project.SetConfigType("toml")
+		if err := project.ReadInConfig(); err != nil {
+			return false, err
+		}
+		if !project.IsSet(pyprojectSection) {
+			return false, nil
+		}
+		viper.SetConfigFile(path)
+		return true, viper.MergeConfigMap(project.GetStringMap(pyprojectSection))
+	}
+
+	viper.SetConfigFile(path)
+	if base == RCFileName || filepath.Ext(base) == "" {
+		viper.SetConfigType("yaml") // .galarc and other extensionless files
+	}
+	return true, viper.ReadInConfig()
+}
+
+// loadConfig finds and reads the config file, then applies its settings and
+// GALA_ environment variables to the flags not given on the command line.
+// Flags take precedence over the environment, which takes precedence over
+// the config file. The config file at the top level of the repository holding
+// config.Directory is only looked for, with git, when the current directory
+// has none.
+func loadConfig(cmd *cobra.Command, config Config) (string, error) {
+	viper.SetEnvPrefix("GALA")
+	viper.SetEnvKeyReplacer(strings.NewReplacer("-", "_"))
+	viper.AutomaticEnv()
+
+	configFile := config.ConfigFile
+	if configFile == "" {
+		configFile = viper.GetString("config")
+	}
+
+	used := ""
+	if configFile != "" {
+		if _, err := readConfig(configFile); err != nil {
+			return "", fmt.Errorf("reading config file %s: %w", configFile, err)
+		}
+		used = configFile
+	} else {
+		var err error
+		used, err = findConfig(configDirPaths("."))
+		if used == "" && err == nil {
+			if !cmd.Flags().Changed("git-path") && viper.IsSet("git-path") {
+				config.GitPath = viper.GetString("git-path") // GALA_GIT_PATH
+			}
+			if root := workingTreeRoot(config); root != "" {
+				used, err = findConfig(configDirPaths(root))
+			}
+		}
+		if used == "" && err == nil {
+			used, err = findConfig(userConfigPaths())
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+
+	var applyErr error
+	cmd.Flags().VisitAll(func(f *pflag.Flag) {
+		if applyErr != nil || f.Changed || f.Name == "config" || !viper.IsSet(f.Name) {
+			return
+		}
+		// Sections such as score: share a name with a flag but aren't values for it
+		if _, ok := viper.Get(f.Name).(map[string]any); ok {
+			return
+		}
+
+		var err error
+		if slice, ok := f.Value.(pflag.SliceValue); ok {
+			err = slice.Replace(viper.GetStringSlice(f.Name))
+		} else {
+			err = f.Value.Set(viper.GetString(f.Name))
+		}
+		if err != nil {
+			applyErr = fmt.Errorf("invalid %s setting: %w", f.Name, err)
+		}
+	})
+
+	return used, applyErr
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestWorkingTreeRoot(t *testing.T) {
+	r := newTestRepo(t)
+	r.write("src/api/main.go", "package main\n")
+
+	config := defaultConfig(filepath.Join(r.dir, "src", "api"))
+	if got := workingTreeRoot(config); got != r.dir {
+		t.Errorf("workingTreeRoot(src/api) = %q, want %q", got, r.dir)
+	}
+
+	if got := workingTreeRoot(defaultConfig(t.TempDir())); got != "" {
+		t.Errorf("workingTreeRoot outside a repository = %q, want none", got)
+	}
+
+	config.GitPath = filepath.Join(t.TempDir(), "no-such-git")
+	if got := workingTreeRoot(config); got != "" {
+		t.Errorf("workingTreeRoot with a missing git = %q, want none", got)
+	}
+}
+
+func TestDefaultConfigMatchesFlags(t *testing.T) {
+	config := defaultConfig("repo")
+	defaults := flagDefaults()
+	if config.OutputFormat != FormatJSON || !config.Quiet || !config.NoProgress || !config.NoCheckpoint ||
+		config.Directory != "repo" {
+		t.Errorf("defaultConfig = %+v, want a quiet JSON analysis of repo without checkpoints", config)
+	}
+
+	// Apart from those, subcommands analyze like a bare run
+	config.OutputFormat, config.Quiet, config.NoProgress, config.NoCheckpoint, config.Directory =
+		defaults.OutputFormat, false, false, false, ""
+	if !reflect.DeepEqual(config, defaults) {
+		t.Errorf("defaultConfig = %+v, want the flag defaults %+v", config, defaults)
+	}
+	if len(config.TierThresholds) != 2 {
+		t.Errorf("TierThresholds = %v, want the core and occasional thresholds", config.TierThresholds)
+	}
+
+	// Each call gets its own thresholds
+	config.TierThresholds[0] = 50
+	if flagDefaults().TierThresholds[0] == 50 {
+		t.Error("flagDefaults shares its TierThresholds")
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// csvColumn is an author column of CSV output, named like the JSON field it
+// holds. Percentage columns set percent instead of value so they follow
+// --precision, and date columns set date so they follow --date-format.
+type csvColumn struct {
+	field   string
+	header  string
+	value   func(AuthorStats) string
+	percent func(AuthorStats) float64
+	date    func(AuthorStats) string
+}
+
+// csvColumns lists the available author columns
+var csvColumns = []csvColumn{
+	{field: "name", header: "Author", value: func(a AuthorStats) string { return a.Name }},
+	{field: "line_count", header: "Lines", value: func(a AuthorStats) string { return strconv.Itoa(a.LineCount) }},
+	{field: "file_count", header: "Files", value: func(a AuthorStats) string { return strconv.Itoa(a.FileCount) }},
+	{field: "lines_per_file", header: "Lines Per File", value: func(a AuthorStats) string { return fmt.Sprintf("%.2f", a.LinesPerFile) }},
+	{field: "commit_count", header: "Commits", value: func(a AuthorStats) string { return strconv.Itoa(a.CommitCount) }},
+	{field: "first_commit", header: "First Commit", date: func(a AuthorStats) string { return a.FirstCommit }},
+	{field: "last_commit", header: "Last Commit", date: func(a AuthorStats) string { return a.LastCommit }},
+	{field: "percentage", header: "Percentage", percent: func(a AuthorStats) float64 { return a.Percentage }},
+	{field: "percentile", header: "Percentile", percent: func(a AuthorStats) float64 { return a.Percentile }},
+	{field: "rank", header: "Rank", value: func(a AuthorStats) string { return strconv.Itoa(a.Rank) }},
+	{field: "weighted_lines", header: "Weighted Lines", value: func(a AuthorStats) string { return fmt.Sprintf("%.2f", a.WeightedLines) }},
+	{field: "score", header: "Score", value: func(a AuthorStats) string { return fmt.Sprintf("%.2f", a.Score) }},
+	{field: "github_handle", header: "GitHub Handle", value: func(a AuthorStats) string { return a.GitHubHandle }},
+	{field: "email", header: "Email", value: func(a AuthorStats) string { return a.Email }},
+}
+
+// defaultCSVColumns are the author columns written without --csv-columns
+var defaultCSVColumns = []string{"name", "line_count", "file_count", "percentage"}
+
+// parseCSVColumns resolves --csv-columns entries, each a field name
+// optionally renamed with "field=Header", into columns in the given order
+func parseCSVColumns(specs []string) ([]csvColumn, error) {
+	if len(specs) == 0 {
+		specs = defaultCSVColumns
+	}
+
+	columns := make([]csvColumn, 0, len(specs))
+	for _, spec := range specs {
+		field, header, renamed := strings.Cut(strings.TrimSpace(spec), "=")
+		field = strings.TrimSpace(field)
+		i := slices.IndexFunc(csvColumns, func(c csvColumn) bool { return c.field == field })
+		if i < 0 {
+			valid := make([]string, len(csvColumns))
+			for j, c := range csvColumns {
+				valid[j] = c.field
+			}
+			return nil, fmt.Errorf("unknown column %q (valid columns: %s)", field, strings.Join(valid, ", "))
+		}
+
+		column := csvColumns[i]
+		if renamed {
+			if header = strings.TrimSpace(header); header == "" {
+				return nil, fmt.Errorf("empty header for column %q", field)
+			}
+			column.header = header
+		}
+		columns = append(columns, column)
+	}
+	return columns, nil
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// datePresets are the named --date-format layouts. Dates are whole days,
+// so rfc3339 is RFC 3339's full-date, the same as iso.
+var datePresets = map[string]string{
+	"iso":     time.DateOnly,
+	"rfc3339": time.DateOnly,
+	"us":      "01/02/2006",
+	"eu":      "02/01/2006",
+	"short":   "Jan 2, 2006",
+	"long":    "January 2, 2006",
+}
+
+// parseDateFormat resolves a --date-format preset or Go time layout. Dates
+// are whole days in UTC, so layouts must contain a date element and no time
+// of day.
+func parseDateFormat(value string) (string, error) {
+	if layout, ok := datePresets[strings.ToLower(value)]; ok {
+		return layout, nil
+	}
+
+	day := time.Date(2001, 2, 3, 0, 0, 0, 0, time.UTC)
+	if day.Format(value) == value {
+		presets := make([]string, 0, len(datePresets))
+		for name := range datePresets {
+			presets = append(presets, name)
+		}
+		sort.Strings(presets)
+		return "", fmt.Errorf("expected a preset (%s) or a Go time layout such as 2006-01-02", strings.Join(presets, ", "))
+	}
+	if day.Add(13*time.Hour+4*time.Minute+5*time.Second).Format(value) != day.Format(value) {
+		return "", fmt.Errorf("dates are whole days, so the layout cannot include a time of day")
+	}
+	return value, nil
+}
+
+// displayDate renders a YYYY-MM-DD date with the --date-format layout. JSON
+// output keeps the YYYY-MM-DD dates whatever the layout.
+func (ga *GitAnalyzer) displayDate(date string) string {
+	if ga.config.DateLayout == "" {
+		return date
+	}
+	t, err := time.Parse(time.DateOnly, date)
+	if err != nil {
+		return date
+	}
+	return t.Format(ga.config.DateLayout)
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ageSpec matches a compact age such as 90d, 6m, "2 weeks" or 1year, which
+// git's date parser would misread ("90d" as 1990)
+var ageSpec = regexp.MustCompile(`^([0-9]+)\s*(d|days?|w|weeks?|m|months?|y|years?)$`)
+
+// parseAge turns a compact age into the date that long before now. Months
+// and years are calendar months and years.
+func parseAge(value string, now time.Time) (time.Time, error) {
+	m := ageSpec.FindStringSubmatch(strings.ToLower(strings.TrimSpace(value)))
+	if m == nil {
+		return time.Time{}, fmt.Errorf("expected a number of days, weeks, months or years, e.g. 90d, 6m or 1y")
+	}
+	n, err := strconv.Atoi(m[1])
+	if err != nil || n == 0 {
+		return time.Time{}, fmt.Errorf("expected a positive age")
+	}
+
+	switch m[2][0] {
+	case 'd':
+		return now.AddDate(0, 0, -n), nil
+	case 'w':
+		return now.AddDate(0, 0, -7*n), nil
+	case 'm':
+		return now.AddDate(0, -n, 0), nil
+	default:
+		return now.AddDate(-n, 0, 0), nil
+	}
+}
+
+// resolveDate parses a YYYY-MM-DD date or a compact age such as 90d, or
+// lets git resolve any other date it understands ("6 months ago",
+// "last.month", "now") so gala's own date filters accept the same syntax as
+// --since and --until
+func (ga *GitAnalyzer) resolveDate(ctx context.Context, value string) (time.Time, error) {
+	if t, err := time.Parse(time.DateOnly, value); err == nil {
+		return t, nil
+	}
+	if ageSpec.MatchString(strings.ToLower(strings.TrimSpace(value))) {
+		return parseAge(value, time.Now())
+	}
+
+	// rev-parse turns --since=<date> into --max-age=<unix timestamp>
+	output, err := ga.gitCommand(ctx, "rev-parse", "--since="+value).Output()
+	if err != nil {
+		return time.Time{}, fmt.Errorf("git rev-parse: %w", err)
+	}
+
+	stamp, ok := strings.CutPrefix(strings.TrimSpace(string(output)), "--max-age=")
+	if !ok {
+		return time.Time{}, fmt.Errorf("unexpected git rev-parse output %q", output)
+	}
+	seconds, err := strconv.ParseInt(stamp, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("unexpected git rev-parse output %q", output)
+	}
+
+	return time.Unix(seconds, 0).UTC(), nil
+}
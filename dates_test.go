@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestResolveDate(t *testing.T) {
+	r := newTestRepo(t)
+	r.commit("Alice", map[string]string{"a.txt": "a\n"})
+	config := defaultConfig(r.dir)
+	config.RecordCommands = true
+	ga := NewGitAnalyzer(config)
+	ctx := context.Background()
+
+	now := time.Now()
+	tests := []struct {
+		value string
+		want  time.Time
+	}{
+		{"2024-01-01", time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"6 months ago", now.AddDate(0, -6, 0)},
+		{"2 weeks ago", now.AddDate(0, 0, -14)},
+		{"3.days.ago", now.AddDate(0, 0, -3)},
+		{"now", now},
+		// Compact ages, which git would misread, are parsed by gala
+		{"90d", now.AddDate(0, 0, -90)},
+		{"6m", now.AddDate(0, -6, 0)},
+		{"2 weeks", now.AddDate(0, 0, -14)},
+		{"1Y", now.AddDate(-1, 0, 0)},
+	}
+	for _, tt := range tests {
+		got, err := ga.resolveDate(ctx, tt.value)
+		if err != nil {
+			t.Errorf("resolveDate(%q): %v", tt.value, err)
+			continue
+		}
+		// git resolves relative dates against its own clock
+		if diff := got.Sub(tt.want).Abs(); diff > time.Minute {
+			t.Errorf("resolveDate(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+
+	// Relative dates reach git through the analyzer's commands
+	recorded := false
+	for _, command := range ga.commands.list() {
+		if strings.Join(command.Args, " ") == "git rev-parse --since=6 months ago" {
+			recorded = true
+		}
+	}
+	if !recorded {
+		t.Errorf("git rev-parse for a relative date wasn't recorded: %+v", ga.commands.list())
+	}
+}
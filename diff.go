@@ -0,0 +1,374 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// DiffFile holds the prior ownership of the lines changed in a file
+type DiffFile struct {
+	Path         string        `json:"path"`
+	ChangedLines int           `json:"changed_lines"`
+	Owners       []AuthorStats `json:"owners"`
+}
+
+// lineRange is a range of lines in the base version of a file
+type lineRange struct {
+	Start int
+	Count int
+}
+
+// diffHunks maps a base-relative file path to its changed line ranges
+type diffHunks map[string][]lineRange
+
+// parseDiffHunks parses `git diff -U0` output into the line ranges each hunk
+// removes or rewrites in the base version. Pure additions have no prior
+// owner and are skipped, as are newly created files.
+func parseDiffHunks(output []byte) (diffHunks, error) {
+	hunks := make(diffHunks)
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	var current string
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		switch {
+		case strings.HasPrefix(line, "--- "):
+			current = ""
+			// git ends the path with a tab when it contains a space
+			path := strings.TrimSuffix(strings.TrimPrefix(line, "--- "), "\t")
+			if path == "/dev/null" {
+				continue
+			}
+			if unquoted, err := strconv.Unquote(path); err == nil {
+				path = unquoted
+			}
+			current = strings.TrimPrefix(path, "a/")
+
+		case strings.HasPrefix(line, "@@ ") && current != "":
+			r, err := parseHunkHeader(line)
+			if err != nil {
+				return nil, err
+			}
+			if r.Count > 0 {
+				hunks[current] = append(hunks[current], r)
+			}
+		}
+	}
+
+	return hunks, scanner.Err()
+}
+
+// parseHunkHeader extracts the base range from a header like "@@ -10,3 +10,4 @@"
+func parseHunkHeader(header string) (lineRange, error) {
+	fields := strings.Fields(header)
+	if len(fields) < 3 || !strings.HasPrefix(fields[1], "-") {
+		return lineRange{}, fmt.Errorf("malformed hunk header %q", header)
+	}
+
+	start, count, found := strings.Cut(strings.TrimPrefix(fields[1], "-"), ",")
+	r := lineRange{Count: 1}
+
+	var err error
+	if r.Start, err = strconv.Atoi(start); err != nil {
+		return lineRange{}, fmt.Errorf("malformed hunk header %q", header)
+	}
+	if found {
+		if r.Count, err = strconv.Atoi(count); err != nil {
+			return lineRange{}, fmt.Errorf("malformed hunk header %q", header)
+		}
+	}
+
+	return r, nil
+}
+
+// resolveCommit resolves a ref given on the command line to a commit hash.
+// --end-of-options keeps git from reading a ref starting with "-" as an
+// option, and the hash is then safe to pass to other commands.
+func (ga *GitAnalyzer) resolveCommit(ctx context.Context, ref string) (string, error) {
+	output, err := ga.gitCommand(ctx, "rev-parse", "--verify", "--quiet", "--end-of-options", ref+"^{commit}").Output()
+	if err != nil {
+		return "", fmt.Errorf("%q is not a commit", ref)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// analyzeDiff blames the changed regions of every file against the base ref
+// to report who owned the touched lines before the change
+func (ga *GitAnalyzer) analyzeDiff(ctx context.Context) (*AnalysisResult, error) {
+	startTime := time.Now()
+
+	if !ga.config.Quiet {
+		ga.logInfo("Analyzing changes since %s", ga.config.DiffBase)
+	}
+
+	base, err := ga.resolveCommit(ctx, ga.config.DiffBase)
+	if err != nil {
+		return nil, err
+	}
+	ga.diffCommit = base
+
+	args := []string{
+		"diff", "-U0", "--no-color", "--no-ext-diff", "-M", "--relative",
+		"--src-prefix=a/", "--dst-prefix=b/", base, "--",
+	}
+	cmd := ga.gitCommand(ctx, args...)
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git diff %s: %w", ga.config.DiffBase, err)
+	}
+
+	hunks, err := parseDiffHunks(output)
+	if err != nil {
+		return nil, err
+	}
+
+	paths := make([]string, 0, len(hunks))
+	for path := range hunks {
+		if !ga.shouldExcludeFile(path) {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+
+	concurrency := ga.config.Concurrency
+	if concurrency <= 0 {
+		concurrency = runtime.NumCPU() * 2
+	}
+
+	files := make([]DiffFile, len(paths))
+	g, ctx := errgroup.WithContext(ctx)
+	g.SetLimit(concurrency)
+
+	for i, path := range paths {
+		g.Go(func() error {
+			authors, err := ga.blameRanges(ctx, path, hunks[path])
+			if err != nil {
+				ga.logWarn("Failed to blame %s, leaving it out: %v", path, err)
+				return nil
+			}
+			files[i] = DiffFile{Path: path, ChangedLines: len(authors), Owners: ga.countOwners(authors)}
+			return nil
+		})
+	}
+
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	// Aggregate owners across files
+	authorCounts := make(map[string]int)
+	authorFiles := make(map[string]int)
+	diffFiles := make([]DiffFile, 0, len(files))
+	totalLines := 0
+
+	for _, file := range files {
+		if file.Path == "" || file.ChangedLines == 0 {
+			continue
+		}
+		diffFiles = append(diffFiles, file)
+		totalLines += file.ChangedLines
+		for _, owner := range file.Owners {
+			authorCounts[owner.Name] += owner.LineCount
+			authorFiles[owner.Name]++
+		}
+	}
+
+	sort.SliceStable(diffFiles, func(i, j int) bool {
+		return diffFiles[i].ChangedLines > diffFiles[j].ChangedLines
+	})
+
+	authors := make([]AuthorStats, 0, len(authorCounts))
+	for name, count := range authorCounts {
+		if count >= ga.config.MinLines {
+			authors = append(authors, AuthorStats{
+				Name:       name,
+				LineCount:  count,
+				FileCount:  authorFiles[name],
+				Percentage: float64(count) / float64(totalLines) * 100,
+			})
+		}
+	}
+
+	assignPercentiles(authors)
+	ga.assignRanks(authors)
+	ga.sortAuthors(authors)
+
+	if ga.config.MaxResults > 0 && len(authors) > ga.config.MaxResults {
+		authors = authors[:ga.config.MaxResults]
+	}
+
+	var coOwners []CoOwnership
+	if ga.writes(FormatDot) {
+		fileAuthors := make(map[string][]string, len(diffFiles))
+		for _, file := range diffFiles {
+			for _, owner := range file.Owners {
+				fileAuthors[file.Path] = append(fileAuthors[file.Path], owner.Name)
+			}
+		}
+		coOwners = coOwnership(authors, fileAuthors)
+	}
+	if ga.config.MaxResults > 0 && len(diffFiles) > ga.config.MaxResults {
+		diffFiles = diffFiles[:ga.config.MaxResults]
+	}
+
+	return &AnalysisResult{
+		Authors:        authors,
+		TotalLines:     totalLines,
+		FilesProcessed: len(diffFiles),
+		TotalFiles:     len(paths),
+		ProcessingTime: time.Since(startTime),
+		Repository:     ga.config.Directory,
+		GeneratedAt:    time.Now(),
+		DiffBase:       ga.config.DiffBase,
+		DiffFiles:      diffFiles,
+		CoOwnership:    coOwners,
+	}, nil
+}
+
+// blameRanges runs git blame on the given line ranges of a file at the base ref
+func (ga *GitAnalyzer) blameRanges(ctx context.Context, path string, ranges []lineRange) ([]string, error) {
+	args := ga.blameArgs()
+	for _, r := range ranges {
+		args = append(args, "-L", fmt.Sprintf("%d,+%d", r.Start, r.Count))
+	}
+	args = append(args, ga.diffCommit, "--", path)
+
+	output, err := ga.gitCommand(ctx, args...).Output()
+	if err != nil {
+		return nil, err
+	}
+
+	return ga.parseBlame(output, path).Authors, nil
+}
+
+// countOwners tallies blamed lines per author, largest owner first
+func (ga *GitAnalyzer) countOwners(authors []string) []AuthorStats {
+	// Merge authors first, so a file owned by several authors merged into
+	// one counts once towards the merged author's files
+	counts := make(map[string]int)
+	for _, author := range authors {
+		counts[ga.tallyName(author)]++
+	}
+
+	owners := make([]AuthorStats, 0, len(counts))
+	for name, count := range counts {
+		owners = append(owners, AuthorStats{
+			Name:       name,
+			LineCount:  count,
+			FileCount:  1,
+			Percentage: float64(count) / float64(len(authors)) * 100,
+		})
+	}
+
+	sort.Slice(owners, func(i, j int) bool {
+		if owners[i].LineCount != owners[j].LineCount {
+			return owners[i].LineCount > owners[j].LineCount
+		}
+		return owners[i].Name < owners[j].Name
+	})
+
+	return owners
+}
+
+// formatOwners renders owners as "Alice (12), Bob (3)"
+func (ga *GitAnalyzer) formatOwners(owners []AuthorStats) string {
+	parts := make([]string, 0, len(owners))
+	for _, owner := range owners {
+		parts = append(parts, fmt.Sprintf("%s (%s)", owner.Name, ga.formatNumber(owner.LineCount)))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// displayDiffResults displays the prior owners of changed lines per file
+func (ga *GitAnalyzer) displayDiffResults(w io.Writer, result *AnalysisResult) error {
+	if !ga.config.Quiet {
+		fmt.Fprintf(w, "\n%s\n", ga.styleHeader(fmt.Sprintf("Prior Owners of Changes Since %s", result.DiffBase)))
+	}
+
+	if len(result.DiffFiles) == 0 {
+		if !ga.config.Quiet {
+			ga.logWarn("No changed lines with prior owners found")
+		}
+		return nil
+	}
+
+	table := ga.newTable(w)
+	table.Header([]string{"Changed", "File", "Prior Owners"})
+
+	for _, file := range result.DiffFiles {
+		table.Append([]string{
+			ga.formatNumber(file.ChangedLines),
+			filepath.ToSlash(file.Path),
+			ga.formatOwners(file.Owners),
+		})
+	}
+
+	table.Render()
+
+	return ga.displayAuthorResults(w, result)
+}
+
+// changedFiles lists the files a branch changed since it forked from base,
+// relative to the analyzed directory, as `git diff --name-only base...HEAD`
+// does. Renamed files are listed under their new path and deleted files are
+// left out, since they have no lines left to blame.
+func (ga *GitAnalyzer) changedFiles(ctx context.Context, base string) (map[string]bool, error) {
+	commit, err := ga.resolveCommit(ctx, base)
+	if err != nil {
+		return nil, err
+	}
+	output, err := ga.gitCommand(ctx, "diff", "--name-only", "-z", "-M", "--diff-filter=d",
+		"--relative", "--no-ext-diff", commit+"...HEAD", "--").Output()
+	if err != nil {
+		return nil, fmt.Errorf("git diff %s...HEAD: %w", base, err)
+	}
+
+	changed := make(map[string]bool)
+	for path := range strings.SplitSeq(string(output), "\x00") {
+		if path != "" {
+			changed[path] = true
+		}
+	}
+	return changed, nil
+}
+
+// filterChanged keeps the files changed since --changed-since, so their
+// current owners can be asked for review
+func (ga *GitAnalyzer) filterChanged(ctx context.Context, files []string) ([]string, error) {
+	changed, err := ga.changedFiles(ctx, ga.config.ChangedSince)
+	if err != nil {
+		return nil, err
+	}
+
+	kept := make([]string, 0, len(changed))
+	for _, file := range files {
+		rel, err := filepath.Rel(ga.config.Directory, file)
+		if err != nil {
+			rel = file
+		}
+		if changed[filepath.ToSlash(rel)] {
+			kept = append(kept, file)
+		} else {
+			ga.explainFile(rel, "unchanged since "+ga.config.ChangedSince)
+		}
+	}
+
+	if !ga.config.Quiet {
+		ga.logInfo("Restricting the analysis to %s files changed since %s",
+			ga.formatNumber(len(kept)), ga.config.ChangedSince)
+	}
+	return kept, nil
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseDiffHunksPathWithSpace(t *testing.T) {
+	output := []byte("diff --git a/foo bar.txt b/foo bar.txt\n" +
+		"index 422c2b7..0f8e8a5 100644\n" +
+		"--- a/foo bar.txt\t\n" +
+		"+++ b/foo bar.txt\t\n" +
+		"@@ -2 +2 @@\n" +
+		"-b\n" +
+		"+c\n")
+
+	hunks, err := parseDiffHunks(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := diffHunks{"foo bar.txt": {{Start: 2, Count: 1}}}
+	if !reflect.DeepEqual(hunks, want) {
+		t.Errorf("parseDiffHunks = %v, want %v", hunks, want)
+	}
+}
+
+func TestCountOwnersMergedAuthors(t *testing.T) {
+	config := defaultConfig(t.TempDir())
+	config.MergeBots = true
+	ga := NewGitAnalyzer(config)
+
+	owners := ga.countOwners([]string{"dependabot[bot]", "Alice", "renovate[bot]", "dependabot[bot]"})
+	want := []AuthorStats{
+		{Name: BotsLabel, LineCount: 3, FileCount: 1, Percentage: 75},
+		{Name: "Alice", LineCount: 1, FileCount: 1, Percentage: 25},
+	}
+	if !reflect.DeepEqual(owners, want) {
+		t.Errorf("countOwners = %+v, want %+v", owners, want)
+	}
+}
+
+func TestDiffMergedAuthorFileCount(t *testing.T) {
+	r := newTestRepo(t)
+	r.commit("dependabot[bot]", map[string]string{"deps.txt": "a\nb\n"})
+	r.commit("renovate[bot]", map[string]string{"deps.txt": "a\nb\nc\nd\n"})
+	r.write("deps.txt", "w\nx\ny\nz\n")
+
+	result := r.analyze(func(c *Config) {
+		c.DiffBase = "HEAD"
+		c.MergeBots = true
+	})
+	if len(result.Authors) != 1 {
+		t.Fatalf("authors = %+v, want only %s", result.Authors, BotsLabel)
+	}
+	if got := result.Authors[0]; got.Name != BotsLabel || got.LineCount != 4 || got.FileCount != 1 {
+		t.Errorf("author = %s with %d lines in %d files, want %s with 4 lines in 1 file",
+			got.Name, got.LineCount, got.FileCount, BotsLabel)
+	}
+}
+
+func TestDiffBaseIsNotAnOption(t *testing.T) {
+	r := newTestRepo(t)
+	r.commit("Alice", map[string]string{"a.txt": "a\n"})
+	r.write("a.txt", "b\n")
+
+	target := filepath.Join(t.TempDir(), "pwned")
+	config := defaultConfig(r.dir)
+	config.DiffBase = "--output=" + target
+	if _, err := NewGitAnalyzer(config).Analyze(context.Background()); err == nil {
+		t.Error("Analyze accepted an option as the --diff base")
+	}
+	if _, err := os.Stat(target); err == nil {
+		t.Errorf("git took the --diff base for an option and wrote %s", target)
+	}
+}
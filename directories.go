@@ -0,0 +1,157 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// directoryOwnersShown is how many top authors each directory lists
+const directoryOwnersShown = 3
+
+// DirectoryStats are the lines of a directory rolled up to --dir-depth, with
+// its largest owners
+type DirectoryStats struct {
+	Path       string           `json:"path"`
+	LineCount  int              `json:"line_count"`
+	FileCount  int              `json:"file_count"`
+	Percentage float64          `json:"percentage"`
+	Authors    int              `json:"author_count"`
+	TopAuthors []DirectoryOwner `json:"top_authors"`
+}
+
+// DirectoryOwner is one of a directory's largest owners
+type DirectoryOwner struct {
+	Name       string  `json:"name"`
+	LineCount  int     `json:"line_count"`
+	Percentage float64 `json:"percentage"` // of the directory's lines
+}
+
+// rollupDirectory returns the directory a file's lines are rolled up into:
+// its first depth directory levels, or the file's own directory when it's
+// shallower, "." for files at the top
+func rollupDirectory(relPath string, depth int) string {
+	dir := path.Dir(filepath.ToSlash(relPath))
+	if dir == "." {
+		return dir
+	}
+	segments := strings.Split(dir, "/")
+	if len(segments) > depth {
+		segments = segments[:depth]
+	}
+	return strings.Join(segments, "/")
+}
+
+// directoryTally collects line counts by rolled-up directory and author
+// while files are processed
+type directoryTally struct {
+	depth  int
+	owners map[string]map[string]int
+	files  map[string]int
+}
+
+func newDirectoryTally(depth int) *directoryTally {
+	return &directoryTally{
+		depth:  depth,
+		owners: make(map[string]map[string]int),
+		files:  make(map[string]int),
+	}
+}
+
+// addFile counts a processed file's lines by directory and owner
+func (t *directoryTally) addFile(relPath string, authors []string) {
+	dir := rollupDirectory(relPath, t.depth)
+	if t.owners[dir] == nil {
+		t.owners[dir] = make(map[string]int)
+	}
+	for _, author := range authors {
+		t.owners[dir][author]++
+	}
+	t.files[dir]++
+}
+
+// directories lists the directories by lines, most first, with percentages
+// of the total lines
+func (t *directoryTally) directories(totalLines int) []DirectoryStats {
+	dirs := make([]DirectoryStats, 0, len(t.owners))
+	for dir, owners := range t.owners {
+		stats := DirectoryStats{Path: dir, FileCount: t.files[dir], Authors: len(owners)}
+		for name, count := range owners {
+			stats.LineCount += count
+			stats.TopAuthors = append(stats.TopAuthors, DirectoryOwner{Name: name, LineCount: count})
+		}
+		if stats.LineCount == 0 {
+			continue // only excluded authors' lines
+		}
+
+		sort.Slice(stats.TopAuthors, func(i, j int) bool {
+			a, b := stats.TopAuthors[i], stats.TopAuthors[j]
+			if a.LineCount != b.LineCount {
+				return a.LineCount > b.LineCount
+			}
+			return a.Name < b.Name
+		})
+		if len(stats.TopAuthors) > directoryOwnersShown {
+			stats.TopAuthors = stats.TopAuthors[:directoryOwnersShown]
+		}
+		for i := range stats.TopAuthors {
+			stats.TopAuthors[i].Percentage = float64(stats.TopAuthors[i].LineCount) / float64(stats.LineCount) * 100
+		}
+		if totalLines > 0 {
+			stats.Percentage = float64(stats.LineCount) / float64(totalLines) * 100
+		}
+		dirs = append(dirs, stats)
+	}
+
+	sort.Slice(dirs, func(i, j int) bool {
+		if dirs[i].LineCount != dirs[j].LineCount {
+			return dirs[i].LineCount > dirs[j].LineCount
+		}
+		return dirs[i].Path < dirs[j].Path
+	})
+	return dirs
+}
+
+// displayDirectoryResults displays the lines rolled up by directory with
+// each directory's top authors
+func (ga *GitAnalyzer) displayDirectoryResults(w io.Writer, result *AnalysisResult) error {
+	if !ga.config.Quiet {
+		fmt.Fprintf(w, "\n%s\n", ga.styleHeader(fmt.Sprintf("Directories (depth %d)", ga.config.DirDepth)))
+	}
+
+	if len(result.Directories) == 0 {
+		if !ga.config.Quiet {
+			ga.logWarn("No authors found matching criteria")
+		}
+		return nil
+	}
+
+	table := ga.newTable(w)
+	table.Header([]string{"Lines", "Files", "Percentage", "Directory", "Top Authors"})
+	for _, dir := range result.Directories {
+		owners := make([]string, len(dir.TopAuthors))
+		for i, owner := range dir.TopAuthors {
+			owners[i] = owner.Name + " (" + ga.formatPercent(owner.Percentage, 1) + ")"
+		}
+		if more := dir.Authors - len(dir.TopAuthors); more > 0 {
+			owners = append(owners, fmt.Sprintf("and %s more", ga.formatNumber(more)))
+		}
+		table.Append([]string{
+			ga.formatNumber(dir.LineCount),
+			ga.formatNumber(dir.FileCount),
+			ga.formatPercent(dir.Percentage, 1),
+			dir.Path,
+			strings.Join(owners, ", "),
+		})
+	}
+	table.Render()
+
+	if !ga.config.Quiet {
+		ga.displaySummary(w, result)
+	}
+
+	return nil
+}
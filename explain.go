@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// explainStderr is the --explain value that traces to stderr
+const explainStderr = "-"
+
+// openExplain starts the --explain trace, on stderr or in the given file.
+// The returned function closes the trace.
+func (ga *GitAnalyzer) openExplain() (func(), error) {
+	if ga.config.Explain == explainStderr {
+		ga.explain = os.Stderr
+		return func() { ga.explain = nil }, nil
+	}
+
+	file, err := os.Create(ga.config.Explain)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create --explain file: %w", err)
+	}
+	ga.explain = file
+	return func() {
+		ga.explain = nil
+		if err := file.Close(); err != nil {
+			ga.logWarn("Failed to write --explain file: %v", err)
+		}
+	}, nil
+}
+
+// explainFile records whether a file is analyzed and, if not, why. An empty
+// reason means the file is included.
+func (ga *GitAnalyzer) explainFile(relPath, reason string) {
+	if ga.explain == nil {
+		return
+	}
+	writeExplanation(ga.explain, filepath.ToSlash(relPath), reason)
+}
+
+// explainDir records a directory that isn't descended into
+func (ga *GitAnalyzer) explainDir(relPath, reason string) {
+	if ga.explain == nil {
+		return
+	}
+	writeExplanation(ga.explain, filepath.ToSlash(relPath)+"/", reason)
+}
+
+// writeExplanation writes "included <path>" or "excluded <path> (<reason>)"
+func writeExplanation(w io.Writer, path, reason string) {
+	if reason == "" {
+		fmt.Fprintf(w, "included %s\n", path)
+		return
+	}
+	fmt.Fprintf(w, "excluded %s (%s)\n", path, reason)
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// FilterTotals compares the lines analyzed with the lines the author and
+// path filters left out, so their effect can be checked at a glance
+type FilterTotals struct {
+	AnalyzedLines int     `json:"analyzed_lines"`
+	TotalLines    int     `json:"total_lines"`           // analyzed plus filtered out
+	Percentage    float64 `json:"percentage"`            // analyzed share of the total
+	AuthorLines   int     `json:"author_filtered_lines"` // lines of analyzed files by excluded authors, or outside the analyzed commits or dates
+	PathLines     int     `json:"path_filtered_lines"`   // lines of files excluded by path filters
+	PathFiles     int     `json:"path_filtered_files"`
+}
+
+// filtersActive reports whether any author or path filter is in effect
+func (ga *GitAnalyzer) filtersActive() bool {
+	c := ga.config
+	return len(c.ExcludeAuthor) > 0 || len(c.IncludeAuthor) > 0 || c.ExcludeBots || c.ExcludeUnknown ||
+		len(c.ExtraPatterns) > 0 || len(ga.includePathRegex) > 0 || len(ga.excludePathRegex) > 0
+}
+
+// foundFile is a file found while listing the files to analyze
+type foundFile struct {
+	path string
+	size int64
+}
+
+// countsFiltered reports whether the lines of path-filtered files are
+// counted, which only the filter totals need
+func (ga *GitAnalyzer) countsFiltered() bool {
+	return ga.config.Username == "" && ga.filtersActive()
+}
+
+// countFiltered counts the lines of the tracked files excluded only by the
+// path filters, when the size, LFS and content checks would have kept them.
+// tracked is nil when every file is tracked, as when listed by git ls-files.
+func (ga *GitAnalyzer) countFiltered(tracked map[string]bool) {
+	for _, file := range ga.pathFiltered {
+		if tracked != nil {
+			relPath, _ := filepath.Rel(ga.config.Directory, file.path)
+			if !tracked[filepath.ToSlash(relPath)] {
+				continue
+			}
+		}
+		if ga.config.MaxFileSize > 0 && file.size > ga.config.MaxFileSize {
+			continue
+		}
+		if !ga.config.IncludeLFS && isLFSPointer(file.path, file.size) {
+			continue
+		}
+		if ga.config.TextOnly && !isTextFile(file.path) {
+			continue
+		}
+
+		lines, err := countFileLines(file.path)
+		if err != nil {
+			continue
+		}
+		ga.filteredFiles++
+		ga.filteredLines += lines
+	}
+	ga.pathFiltered = nil
+}
+
+// countFileLines counts the lines of a file the way blame does, including a
+// last line without a trailing newline, reading it a block at a time
+func countFileLines(path string) (int, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	buf := make([]byte, 32*1024)
+	lines := 0
+	unterminated := false
+	for {
+		n, err := file.Read(buf)
+		if n > 0 {
+			lines += bytes.Count(buf[:n], []byte{'\n'})
+			unterminated = buf[n-1] != '\n'
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+	if unterminated {
+		lines++
+	}
+	return lines, nil
+}
+
+// filterTotals returns the analyzed and unfiltered totals when a filter is
+// in effect, nil otherwise. untrimmed counts the lines of the analyzed
+// files, including those left out by the author filters.
+func (ga *GitAnalyzer) filterTotals(analyzed, untrimmed int) *FilterTotals {
+	if !ga.filtersActive() {
+		return nil
+	}
+
+	totals := &FilterTotals{
+		AnalyzedLines: analyzed,
+		TotalLines:    untrimmed + ga.filteredLines,
+		AuthorLines:   untrimmed - analyzed,
+		PathLines:     ga.filteredLines,
+		PathFiles:     ga.filteredFiles,
+	}
+	if totals.TotalLines > 0 {
+		totals.Percentage = float64(analyzed) / float64(totals.TotalLines) * 100
+	}
+	return totals
+}
@@ -0,0 +1,28 @@
+package main
+
+import "testing"
+
+func TestFilterTotalsCountTrackedFiles(t *testing.T) {
+	r := newTestRepo(t)
+	r.commit("Alice", map[string]string{
+		"src/a.go":  lines("a", 2),
+		"docs/x.md": lines("x", 3),
+		"docs/y.md": "no trailing newline",
+	})
+	r.write("docs/untracked.md", lines("u", 5))
+
+	for _, lsFiles := range []bool{false, true} {
+		result := r.analyze(func(c *Config) {
+			c.ExcludePathRegex = []string{"^docs/"}
+			c.UseGitLsFiles = lsFiles
+		})
+		ft := result.FilterTotals
+		if ft == nil {
+			t.Fatalf("ls-files %v: no filter totals", lsFiles)
+		}
+		if ft.AnalyzedLines != 2 || ft.PathLines != 4 || ft.PathFiles != 2 || ft.TotalLines != 6 {
+			t.Errorf("ls-files %v: totals = %+v, want 2 analyzed of 6, with 4 lines in 2 path-filtered files",
+				lsFiles, *ft)
+		}
+	}
+}
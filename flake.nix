@@ -24,6 +24,7 @@
       # Version and build info
       version = "1.0.0";
       gitCommit = self.rev or self.dirtyRev or "dev";
+      buildDate = self.lastModifiedDate or "unknown";
 
     in
     flake-utils.lib.eachSystem supportedSystems (
@@ -62,6 +63,7 @@
             "-w"
             "-X main.Version=${version}"
             "-X main.GitCommit=${gitCommit}"
+            "-X main.BuildDate=${buildDate}"
           ];
 
           postInstall = ''
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// minGitVersion is the oldest git supporting every option gala passes,
+// the newest being blame's --ignore-revs-file
+var minGitVersion = [2]int{2, 23}
+
+// checkGit fails fast when the git executable is missing or broken, instead
+// of letting every file's blame fail, and warns when git is too old
+func (ga *GitAnalyzer) checkGit(ctx context.Context) error {
+	gitPath := ga.config.GitPath
+	if gitPath == "" {
+		gitPath = "git"
+	}
+
+	resolved, err := exec.LookPath(gitPath)
+	if err != nil {
+		return &ExitError{
+			Code: ExitCodeGit,
+			Err:  fmt.Errorf("git not found (%s); install git or point --git-path at it", gitPath),
+		}
+	}
+
+	output, err := exec.CommandContext(ctx, resolved, "--version").Output()
+	if err != nil {
+		if errors.Is(ctx.Err(), context.Canceled) {
+			return ctx.Err()
+		}
+		return &ExitError{
+			Code: ExitCodeGit,
+			Err:  fmt.Errorf("%s --version failed: %w", resolved, err),
+		}
+	}
+
+	version := strings.TrimSpace(string(output))
+	major, minor, ok := parseGitVersion(version)
+	if !ok {
+		ga.logWarn("Unrecognized git version %q; results may be wrong", version)
+		return nil
+	}
+	ga.gitVersion = [2]int{major, minor}
+	if !ga.gitAtLeast(minGitVersion) {
+		ga.logWarn("git %d.%d is older than the supported minimum %d.%d; blame options may fail or behave differently",
+			major, minor, minGitVersion[0], minGitVersion[1])
+	}
+
+	ga.logDebug("Using %s (%s)", resolved, version)
+	return nil
+}
+
+// gitAtLeast reports whether the git version checked by checkGit is at
+// least the given one, assuming it is when the version is unrecognized
+func (ga *GitAnalyzer) gitAtLeast(version [2]int) bool {
+	if ga.gitVersion == [2]int{} {
+		return true
+	}
+	return ga.gitVersion[0] > version[0] ||
+		(ga.gitVersion[0] == version[0] && ga.gitVersion[1] >= version[1])
+}
+
+// parseGitVersion extracts the major and minor version from `git --version`
+// output such as "git version 2.39.3 (Apple Git-146)" or
+// "git version 2.45.1.windows.1"
+func parseGitVersion(output string) (major, minor int, ok bool) {
+	fields := strings.Fields(output)
+	if len(fields) < 3 || fields[0] != "git" || fields[1] != "version" {
+		return 0, 0, false
+	}
+
+	parts := strings.Split(fields[2], ".")
+	if len(parts) < 2 {
+		return 0, 0, false
+	}
+
+	var err error
+	if major, err = strconv.Atoi(parts[0]); err != nil {
+		return 0, 0, false
+	}
+	if minor, err = strconv.Atoi(parts[1]); err != nil {
+		return 0, 0, false
+	}
+	return major, minor, true
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// CoOwnership connects two authors who both own lines in the same files
+type CoOwnership struct {
+	Source string `json:"source"`
+	Target string `json:"target"`
+	Files  int    `json:"files"`
+}
+
+// coOwnership counts, for every pair of the given authors, the files both
+// own lines in. fileAuthors maps each file to the authors owning lines in it.
+func coOwnership(authors []AuthorStats, fileAuthors map[string][]string) []CoOwnership {
+	listed := make(map[string]bool, len(authors))
+	for _, author := range authors {
+		listed[author.Name] = true
+	}
+
+	type pair struct{ a, b string }
+	shared := make(map[pair]int)
+
+	for _, owners := range fileAuthors {
+		names := make([]string, 0, len(owners))
+		for _, name := range owners {
+			if listed[name] {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+
+		for i := 0; i < len(names); i++ {
+			for j := i + 1; j < len(names); j++ {
+				shared[pair{names[i], names[j]}]++
+			}
+		}
+	}
+
+	edges := make([]CoOwnership, 0, len(shared))
+	for p, files := range shared {
+		edges = append(edges, CoOwnership{Source: p.a, Target: p.b, Files: files})
+	}
+
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i].Files != edges[j].Files {
+			return edges[i].Files > edges[j].Files
+		}
+		if edges[i].Source != edges[j].Source {
+			return edges[i].Source < edges[j].Source
+		}
+		return edges[i].Target < edges[j].Target
+	})
+
+	return edges
+}
+
+// filesByAuthor inverts an author to file set mapping
+func filesByAuthor(authorFiles map[string]map[string]bool) map[string][]string {
+	fileAuthors := make(map[string][]string)
+	for author, files := range authorFiles {
+		for file := range files {
+			fileAuthors[file] = append(fileAuthors[file], author)
+		}
+	}
+	return fileAuthors
+}
+
+// dotQuote renders s as a quoted Graphviz ID
+func dotQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s) + `"`
+}
+
+// outputDot outputs the author co-ownership graph in Graphviz DOT format.
+// Authors are nodes; an edge joins two authors who own lines in the same
+// files, weighted by the number of such files.
+func (ga *GitAnalyzer) outputDot(w io.Writer, result *AnalysisResult) error {
+	fmt.Fprintln(w, "graph gala {")
+	fmt.Fprintln(w, "  node [shape=box];")
+
+	for _, author := range result.Authors {
+		label := fmt.Sprintf("%s\n%s lines, %s files", author.Name,
+			ga.formatNumber(author.LineCount), ga.formatNumber(author.FileCount))
+		fmt.Fprintf(w, "  %s [label=%s];\n", dotQuote(author.Name), dotQuote(label))
+	}
+
+	for _, edge := range result.CoOwnership {
+		fmt.Fprintf(w, "  %s -- %s [weight=%d, label=\"%d\"];\n",
+			dotQuote(edge.Source), dotQuote(edge.Target), edge.Files, edge.Files)
+	}
+
+	_, err := fmt.Fprintln(w, "}")
+	return err
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// HandlesFileName is the mapping file of GitHub handles looked up at the top
+// level of the repository with --resolve-handles
+const HandlesFileName = ".gala-handles"
+
+// noreplyEmail matches GitHub's private commit emails, both the current
+// "<id>+<user>@users.noreply.github.com" form and the older
+// "<user>@users.noreply.github.com" one
+var noreplyEmail = regexp.MustCompile(`(?i)^(?:\d+\+)?([a-z0-9](?:[a-z0-9-]*[a-z0-9])?)@users\.noreply\.github\.com$`)
+
+// githubHandle matches a valid GitHub username
+var githubHandle = regexp.MustCompile(`(?i)^[a-z0-9](?:[a-z0-9-]*[a-z0-9])?$`)
+
+// loadHandles reads the --handles-file mapping, or HandlesFileName at the
+// top level of the repository when present. Each line maps an author's email or name
+// to a handle, which is the last field and may start with "@":
+//
+//	jane@example.com   @jane-doe
+//	Jane Doe           jane-doe
+//
+// Blank lines and lines starting with "#" are ignored. Keys are matched
+// case-insensitively.
+func (ga *GitAnalyzer) loadHandles() (map[string]string, error) {
+	path := ga.config.HandlesFile
+	if path == "" {
+		path = filepath.Join(ga.repoRoot, HandlesFileName)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		if ga.config.HandlesFile == "" && errors.Is(err, fs.ErrNotExist) {
+			return map[string]string{}, nil
+		}
+		return nil, err
+	}
+	defer file.Close()
+
+	handles := make(map[string]string)
+	scanner := bufio.NewScanner(file)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		i := strings.LastIndexAny(line, " \t")
+		if i < 0 {
+			return nil, fmt.Errorf("%s:%d: expected an email or name followed by a handle", path, lineNum)
+		}
+		key := strings.TrimSpace(line[:i])
+		key = strings.TrimSuffix(strings.TrimPrefix(key, "<"), ">")
+		handle := strings.TrimPrefix(line[i+1:], "@")
+		if !githubHandle.MatchString(handle) {
+			return nil, fmt.Errorf("%s:%d: invalid GitHub handle %q", path, lineNum, handle)
+		}
+		handles[strings.ToLower(key)] = handle
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	ga.logDebug("Loaded %d GitHub handles from %s", len(handles), path)
+	return handles, nil
+}
+
+// resolveHandle finds an author's GitHub handle. The mapping file is
+// consulted first, by each of the author's emails and then by name, before
+// falling back to a GitHub noreply email. Emails are tried in sorted order so
+// authors with several emails resolve the same way on every run. It returns
+// "" when no handle can be derived.
+func (ga *GitAnalyzer) resolveHandle(name string, emails map[string]bool) string {
+	sorted := make([]string, 0, len(emails))
+	for email := range emails {
+		sorted = append(sorted, email)
+	}
+	sort.Strings(sorted)
+
+	for _, email := range sorted {
+		if handle, ok := ga.handles[strings.ToLower(email)]; ok {
+			return handle
+		}
+	}
+	if handle, ok := ga.handles[strings.ToLower(name)]; ok {
+		return handle
+	}
+	for _, email := range sorted {
+		if m := noreplyEmail.FindStringSubmatch(email); m != nil {
+			return m[1]
+		}
+	}
+	return ""
+}
+
+// mention formats a handle as an @-mention, or "" without one
+func mention(handle string) string {
+	if handle == "" {
+		return ""
+	}
+	return "@" + handle
+}
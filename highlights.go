@@ -0,0 +1,158 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// Highlights summarizes an analysis at a glance for --rich-summary. Each
+// highlight is omitted when the data it needs wasn't collected.
+type Highlights struct {
+	MostActive       *AuthorHighlight    `json:"most_active_author,omitempty"`
+	TopDirectory     *DirectoryHighlight `json:"top_directory,omitempty"`
+	DominantLanguage *LanguageHighlight  `json:"dominant_language,omitempty"`
+	Oldest           *AuthorHighlight    `json:"oldest_contribution,omitempty"`
+	Newest           *AuthorHighlight    `json:"newest_contribution,omitempty"`
+}
+
+// AuthorHighlight names an author with the value that singles them out
+type AuthorHighlight struct {
+	Name       string  `json:"name"`
+	Lines      int     `json:"lines,omitempty"`
+	Percentage float64 `json:"percentage,omitempty"`
+	Date       string  `json:"date,omitempty"`
+}
+
+// DirectoryHighlight is the top-level directory with the most lines and its
+// largest owner
+type DirectoryHighlight struct {
+	Path       string  `json:"path"`
+	Lines      int     `json:"lines"`
+	Owner      string  `json:"owner"`
+	OwnerShare float64 `json:"owner_percentage"`
+}
+
+// LanguageHighlight is the language with the most lines
+type LanguageHighlight struct {
+	Name       string  `json:"name"`
+	Lines      int     `json:"lines"`
+	Percentage float64 `json:"percentage"`
+}
+
+// highlightTally collects per-directory and per-language line counts while
+// files are processed
+type highlightTally struct {
+	dirOwners map[string]map[string]int
+	langLines map[string]int
+	total     int
+}
+
+func newHighlightTally() *highlightTally {
+	return &highlightTally{
+		dirOwners: make(map[string]map[string]int),
+		langLines: make(map[string]int),
+	}
+}
+
+// topDirectory returns the first segment of a relative path, or "." for
+// files at the root
+func topDirectory(relPath string) string {
+	dir, _, found := strings.Cut(filepath.ToSlash(relPath), "/")
+	if !found {
+		return "."
+	}
+	return dir
+}
+
+// addFile counts a processed file's lines by directory, owner and language
+func (t *highlightTally) addFile(path, relPath string, authors []string) {
+	dir := topDirectory(relPath)
+	if t.dirOwners[dir] == nil {
+		t.dirOwners[dir] = make(map[string]int)
+	}
+	for _, author := range authors {
+		t.dirOwners[dir][author]++
+	}
+
+	t.total += len(authors)
+	if lang := detectLanguage(path); lang != "" {
+		t.langLines[lang] += len(authors)
+	}
+}
+
+// highlights picks the highlights from the ranked authors, their spans and
+// the directory and language tallies
+func (t *highlightTally) highlights(authors []AuthorStats, spans map[string]authorSpan) *Highlights {
+	h := &Highlights{}
+
+	for _, author := range authors {
+		if h.MostActive == nil || author.LineCount > h.MostActive.Lines {
+			h.MostActive = &AuthorHighlight{Name: author.Name, Lines: author.LineCount, Percentage: author.Percentage}
+		}
+
+		span, ok := spans[author.Name]
+		if !ok || span.Last == 0 {
+			continue
+		}
+		if h.Oldest == nil || span.First < spans[h.Oldest.Name].First {
+			h.Oldest = &AuthorHighlight{Name: author.Name, Date: formatCommitDate(span.First)}
+		}
+		if h.Newest == nil || span.Last > spans[h.Newest.Name].Last {
+			h.Newest = &AuthorHighlight{Name: author.Name, Date: formatCommitDate(span.Last)}
+		}
+	}
+
+	for dir, owners := range t.dirOwners {
+		lines, owner, ownerLines := 0, "", 0
+		for name, count := range owners {
+			lines += count
+			if count > ownerLines || (count == ownerLines && name < owner) {
+				owner, ownerLines = name, count
+			}
+		}
+		if h.TopDirectory == nil || lines > h.TopDirectory.Lines ||
+			(lines == h.TopDirectory.Lines && dir < h.TopDirectory.Path) {
+			h.TopDirectory = &DirectoryHighlight{
+				Path:       dir,
+				Lines:      lines,
+				Owner:      owner,
+				OwnerShare: float64(ownerLines) / float64(lines) * 100,
+			}
+		}
+	}
+
+	for lang, lines := range t.langLines {
+		if h.DominantLanguage == nil || lines > h.DominantLanguage.Lines ||
+			(lines == h.DominantLanguage.Lines && lang < h.DominantLanguage.Name) {
+			h.DominantLanguage = &LanguageHighlight{
+				Name:       lang,
+				Lines:      lines,
+				Percentage: float64(lines) / float64(t.total) * 100,
+			}
+		}
+	}
+
+	return h
+}
+
+// highlightRows renders the highlights as summary table rows
+func (ga *GitAnalyzer) highlightRows(h *Highlights) [][]string {
+	var rows [][]string
+	if a := h.MostActive; a != nil {
+		rows = append(rows, []string{"Most active author", a.Name + " (" + ga.formatPercent(a.Percentage, 1) + ")"})
+	}
+	if d := h.TopDirectory; d != nil {
+		rows = append(rows, []string{"Largest directory", d.Path + " (" + ga.formatNumber(d.Lines) + " lines, " +
+			ga.formatPercent(d.OwnerShare, 1) + " " + d.Owner + ")"})
+	}
+	if l := h.DominantLanguage; l != nil {
+		rows = append(rows, []string{"Dominant language", l.Name + " (" + ga.formatPercent(l.Percentage, 1) + ")"})
+	}
+	if a := h.Oldest; a != nil {
+		rows = append(rows, []string{"Oldest line", ga.displayDate(a.Date) + " by " + a.Name})
+	}
+	if a := h.Newest; a != nil {
+		rows = append(rows, []string{"Newest line", ga.displayDate(a.Date) + " by " + a.Name})
+	}
+	return rows
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// HistoryStats is an author's share of a file's history across renames
+type HistoryStats struct {
+	Name    string `json:"name"`
+	Commits int    `json:"commits"`
+	Added   int    `json:"lines_added"`
+	Deleted int    `json:"lines_deleted"`
+}
+
+// fileHistory attributes every change to a file over its whole life, under
+// any of its former names, using git log --follow. It returns the per-author
+// totals, most lines added first, and the file's former paths.
+func (ga *GitAnalyzer) fileHistory(ctx context.Context, path string) ([]HistoryStats, []string, error) {
+	role := "%an"
+	if ga.config.Credit == CreditCommitter {
+		role = "%cn"
+	}
+
+	// Each commit is "\x1e<name>\x00\n<added>\t<deleted>\t<path>\x00", where
+	// a rename replaces <path> with "\x00<old>\x00<new>"
+	output, err := ga.gitCommand(ctx, "log", "--follow", "-M", "--numstat", "-z",
+		"--format=%x1e"+role, "--", path).Output()
+	if err != nil {
+		return nil, nil, fmt.Errorf("git log --follow %s: %w", path, err)
+	}
+
+	stats := make(map[string]*HistoryStats)
+	var formerPaths []string
+	seenPaths := map[string]bool{path: true}
+	addPath := func(p string) {
+		if p != "" && !seenPaths[p] {
+			seenPaths[p] = true
+			formerPaths = append(formerPaths, p)
+		}
+	}
+
+	for record := range strings.SplitSeq(string(output), "\x1e") {
+		name, numstat, ok := strings.Cut(record, "\x00")
+		if !ok {
+			continue
+		}
+		name = ga.knownName(decodeGitName(name))
+		if ga.shouldExcludeAuthor(name) {
+			continue
+		}
+		author := ga.tallyName(name)
+
+		if stats[author] == nil {
+			stats[author] = &HistoryStats{Name: author}
+		}
+		stats[author].Commits++
+
+		numstat = strings.TrimPrefix(numstat, "\n")
+		added, rest, _ := strings.Cut(numstat, "\t")
+		deleted, rest, _ := strings.Cut(rest, "\t")
+		if strings.HasPrefix(rest, "\x00") {
+			old, rest, _ := strings.Cut(rest[1:], "\x00")
+			renamed, _, _ := strings.Cut(rest, "\x00")
+			addPath(renamed)
+			addPath(old)
+		}
+
+		// Binary changes are reported as "-"
+		if n, err := strconv.Atoi(added); err == nil {
+			stats[author].Added += n
+		}
+		if n, err := strconv.Atoi(deleted); err == nil {
+			stats[author].Deleted += n
+		}
+	}
+
+	history := make([]HistoryStats, 0, len(stats))
+	for _, s := range stats {
+		history = append(history, *s)
+	}
+	sort.Slice(history, func(i, j int) bool {
+		if history[i].Added != history[j].Added {
+			return history[i].Added > history[j].Added
+		}
+		return history[i].Name < history[j].Name
+	})
+
+	return history, formerPaths, nil
+}
+
+// displayFileHistory displays who changed a file over its whole life
+func (ga *GitAnalyzer) displayFileHistory(w io.Writer, result *AnalysisResult) {
+	if len(result.History) == 0 {
+		return
+	}
+
+	if !ga.config.Quiet {
+		fmt.Fprintf(w, "\n%s\n", ga.styleHeader("History (Following Renames)"))
+		if len(result.FormerPaths) > 0 {
+			fmt.Fprintf(w, "Former names: %s\n", strings.Join(result.FormerPaths, ", "))
+		}
+	}
+
+	table := ga.newTable(w)
+	table.Header([]string{"Commits", "Added", "Deleted", "Author"})
+	for _, author := range result.History {
+		table.Append([]string{
+			ga.formatNumber(author.Commits),
+			ga.formatNumber(author.Added),
+			ga.formatNumber(author.Deleted),
+			author.Name,
+		})
+	}
+	table.Render()
+}
+
+// addHistory sets the lines each user-view file gained from the user over
+// its whole history, following renames
+func (ga *GitAnalyzer) addHistory(ctx context.Context, contributions []FileContribution) {
+	user := ga.tallyName(ga.config.Username)
+	for i := range contributions {
+		history, _, err := ga.fileHistory(ctx, filepath.ToSlash(contributions[i].Path))
+		if err != nil {
+			ga.logWarn("Failed to follow %s: %v", contributions[i].Path, err)
+			continue
+		}
+		for _, author := range history {
+			if author.Name == user {
+				contributions[i].LinesAdded = author.Added
+			}
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// IgnoreRevsFileName is the conventional list of commits for blame to skip,
+// such as mass reformatting
+const IgnoreRevsFileName = ".git-blame-ignore-revs"
+
+// ignoreRevsGitVersion is the first git version able to skip commits in blame
+var ignoreRevsGitVersion = [2]int{2, 23}
+
+// detectIgnoreRevs looks for a .git-blame-ignore-revs file at the top level
+// of the repository and returns its path, or "" when there is none
+func (ga *GitAnalyzer) detectIgnoreRevs() string {
+	path := filepath.Join(ga.repoRoot, IgnoreRevsFileName)
+	if info, err := os.Stat(path); err != nil || !info.Mode().IsRegular() {
+		return ""
+	}
+	return path
+}
+
+// readIgnoreRevs reads the revisions listed in an ignore-revs file. Comments
+// run from "#" to the end of the line, whether the line holds a revision or
+// not, and blank lines are ignored.
+func readIgnoreRevs(r io.Reader) ([]string, error) {
+	var revs []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		if rev := strings.TrimSpace(line); rev != "" {
+			revs = append(revs, rev)
+		}
+	}
+	return revs, scanner.Err()
+}
+
+// loadIgnoreRevs resolves the commits listed in the ignore-revs file at path,
+// which blame then skips with one --ignore-rev each rather than reading the
+// file itself. Git rejects the whole file over an abbreviated hash, and
+// --ignore-rev over a commit missing from the repository, for example one
+// outside a shallow clone, so both are resolved or dropped here first.
+func (ga *GitAnalyzer) loadIgnoreRevs(ctx context.Context, path string) error {
+	if !ga.gitAtLeast(ignoreRevsGitVersion) {
+		ga.logWarn("git %d.%d can't skip the commits listed in %s; blaming without them",
+			ga.gitVersion[0], ga.gitVersion[1], IgnoreRevsFileName)
+		return nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	revs, err := readIgnoreRevs(file)
+	if err != nil || len(revs) == 0 {
+		return err
+	}
+
+	hashes, err := ga.resolveCommits(ctx, revs)
+	if err != nil {
+		return err
+	}
+	seen := make(map[string]bool, len(hashes))
+	missing := 0
+	for i, hash := range hashes {
+		if hash == "" {
+			ga.logDebug("Not ignoring %q from %s: not a commit in this repository", revs[i], IgnoreRevsFileName)
+			missing++
+			continue
+		}
+		if !seen[hash] {
+			seen[hash] = true
+			ga.ignoreRevs = append(ga.ignoreRevs, hash)
+		}
+	}
+
+	if missing > 0 {
+		ga.logWarn("%d of the %d revisions in %s aren't commits in this repository and won't be ignored",
+			missing, len(revs), IgnoreRevsFileName)
+	}
+	return nil
+}
+
+// ignoreRevsArgs returns the blame arguments selecting the commits to skip.
+// With --no-ignore-revs, an empty file name also clears any
+// blame.ignoreRevsFile from the git configuration.
+func (ga *GitAnalyzer) ignoreRevsArgs() []string {
+	if ga.config.NoIgnoreRevs {
+		return []string{"--ignore-revs-file="}
+	}
+	args := make([]string, 0, len(ga.ignoreRevs))
+	for _, rev := range ga.ignoreRevs {
+		args = append(args, "--ignore-rev="+rev)
+	}
+	return args
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadIgnoreRevs(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []string
+	}{
+		{"empty", "", nil},
+		{"plain", "abc123\ndef456\n", []string{"abc123", "def456"}},
+		{"comment lines", "# Reformat with gofmt\nabc123\n#def456\n", []string{"abc123"}},
+		{"trailing comments", "abc123 # gofmt\ndef456\t# prettier\n", []string{"abc123", "def456"}},
+		{"blank and indented", "\n   \n  abc123  \n\n", []string{"abc123"}},
+		{"no final newline", "abc123", []string{"abc123"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readIgnoreRevs(strings.NewReader(tt.input))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("readIgnoreRevs(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadIgnoreRevsGitVersion(t *testing.T) {
+	r := newTestRepo(t)
+	r.commit("Alice", map[string]string{"a.txt": "a\n"})
+	head := strings.TrimSpace(r.git("rev-parse", "HEAD"))
+	path := filepath.Join(r.dir, IgnoreRevsFileName)
+	if err := os.WriteFile(path, []byte("# formatting\n"+head+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		version [2]int
+		want    []string
+	}{
+		{[2]int{2, 22}, nil},
+		{[2]int{1, 9}, nil},
+		{[2]int{2, 23}, []string{head}},
+		{[2]int{3, 0}, []string{head}},
+	}
+	for _, tt := range tests {
+		ga := NewGitAnalyzer(defaultConfig(r.dir))
+		ga.gitVersion = tt.version
+		if err := ga.loadIgnoreRevs(context.Background(), path); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(ga.ignoreRevs, tt.want) {
+			t.Errorf("git %d.%d: ignoreRevs = %q, want %q", tt.version[0], tt.version[1], ga.ignoreRevs, tt.want)
+		}
+	}
+}
+
+func TestBlameSkipsIgnoredRevs(t *testing.T) {
+	r := newTestRepo(t)
+	// Whitespace changes are ignored anyway, so the reformat adds semicolons
+	r.commit("Alice", map[string]string{"src/calc.go": "x := 1\ny := 2\nz := x + y\n"})
+	r.commit("Bob", map[string]string{"src/calc.go": "x := 1;\ny := 2;\nz := x + y;\n"})
+	reformat := strings.TrimSpace(r.git("rev-parse", "HEAD"))
+
+	blamed := r.analyze(func(c *Config) { c.Languages = []string{"Go"} })
+	if got := authorLines(blamed)["Bob"]; got == 0 {
+		t.Fatalf("without %s Bob owns no lines; the fixture doesn't exercise the file", IgnoreRevsFileName)
+	}
+
+	r.commit("Carol", map[string]string{IgnoreRevsFileName: "# gofmt\n" + reformat + "\n"})
+	result := r.analyze(func(c *Config) { c.Languages = []string{"Go"} })
+	if got, want := authorLines(result), map[string]int{"Alice": 3}; !reflect.DeepEqual(got, want) {
+		t.Errorf("author lines = %v, want %v", got, want)
+	}
+
+	skipped := r.analyze(func(c *Config) {
+		c.Languages = []string{"Go"}
+		c.NoIgnoreRevs = true
+	})
+	if got := authorLines(skipped)["Bob"]; got == 0 {
+		t.Errorf("with --no-ignore-revs Bob owns no lines")
+	}
+}
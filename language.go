@@ -0,0 +1,257 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// languageByFilename classifies files whose name alone determines the language
+var languageByFilename = map[string]string{
+	"Dockerfile":     "Dockerfile",
+	"Containerfile":  "Dockerfile",
+	"Makefile":       "Makefile",
+	"GNUmakefile":    "Makefile",
+	"CMakeLists.txt": "CMake",
+	"Gemfile":        "Ruby",
+	"Rakefile":       "Ruby",
+	"Jenkinsfile":    "Groovy",
+	"Vagrantfile":    "Ruby",
+	"BUILD":          "Starlark",
+	"BUILD.bazel":    "Starlark",
+	"WORKSPACE":      "Starlark",
+}
+
+// languageByExtension classifies files by their extension
+var languageByExtension = map[string]string{
+	".go":     "Go",
+	".py":     "Python",
+	".pyi":    "Python",
+	".js":     "JavaScript",
+	".mjs":    "JavaScript",
+	".cjs":    "JavaScript",
+	".jsx":    "JavaScript",
+	".ts":     "TypeScript",
+	".mts":    "TypeScript",
+	".cts":    "TypeScript",
+	".tsx":    "TSX",
+	".rs":     "Rust",
+	".c":      "C",
+	".h":      "C",
+	".cc":     "C++",
+	".cpp":    "C++",
+	".cxx":    "C++",
+	".hh":     "C++",
+	".hpp":    "C++",
+	".m":      "Objective-C",
+	".mm":     "Objective-C++",
+	".java":   "Java",
+	".kt":     "Kotlin",
+	".kts":    "Kotlin",
+	".scala":  "Scala",
+	".groovy": "Groovy",
+	".cs":     "C#",
+	".fs":     "F#",
+	".swift":  "Swift",
+	".rb":     "Ruby",
+	".php":    "PHP",
+	".pl":     "Perl",
+	".pm":     "Perl",
+	".lua":    "Lua",
+	".r":      "R",
+	".dart":   "Dart",
+	".ex":     "Elixir",
+	".exs":    "Elixir",
+	".erl":    "Erlang",
+	".hrl":    "Erlang",
+	".clj":    "Clojure",
+	".cljs":   "Clojure",
+	".hs":     "Haskell",
+	".ml":     "OCaml",
+	".mli":    "OCaml",
+	".zig":    "Zig",
+	".nim":    "Nim",
+	".jl":     "Julia",
+	".sh":     "Shell",
+	".bash":   "Shell",
+	".zsh":    "Shell",
+	".fish":   "fish",
+	".ps1":    "PowerShell",
+	".sql":    "SQL",
+	".html":   "HTML",
+	".htm":    "HTML",
+	".css":    "CSS",
+	".scss":   "SCSS",
+	".sass":   "Sass",
+	".less":   "Less",
+	".vue":    "Vue",
+	".svelte": "Svelte",
+	".md":     "Markdown",
+	".rst":    "reStructuredText",
+	".yaml":   "YAML",
+	".yml":    "YAML",
+	".json":   "JSON",
+	".toml":   "TOML",
+	".xml":    "XML",
+	".proto":  "Protocol Buffer",
+	".tf":     "HCL",
+	".hcl":    "HCL",
+	".nix":    "Nix",
+	".mk":     "Makefile",
+	".cmake":  "CMake",
+	".bzl":    "Starlark",
+	".gradle": "Groovy",
+	".vim":    "Vim Script",
+	".el":     "Emacs Lisp",
+}
+
+// languageByInterpreter classifies extensionless scripts by their shebang
+var languageByInterpreter = map[string]string{
+	"sh":      "Shell",
+	"bash":    "Shell",
+	"zsh":     "Shell",
+	"dash":    "Shell",
+	"ksh":     "Shell",
+	"fish":    "fish",
+	"python":  "Python",
+	"python2": "Python",
+	"python3": "Python",
+	"node":    "JavaScript",
+	"deno":    "TypeScript",
+	"ruby":    "Ruby",
+	"perl":    "Perl",
+	"php":     "PHP",
+	"lua":     "Lua",
+	"Rscript": "R",
+	"pwsh":    "PowerShell",
+}
+
+// normalizeLanguage makes language names comparable, treating case, spaces
+// and hyphens alike ("protocol-buffer" matches "Protocol Buffer")
+func normalizeLanguage(name string) string {
+	return strings.ToLower(strings.ReplaceAll(name, " ", "-"))
+}
+
+// detectLanguage classifies a file by name, extension and, for extensionless
+// files, shebang. It returns "" when the language is unknown.
+func detectLanguage(path string) string {
+	base := filepath.Base(path)
+	if lang, ok := languageByFilename[base]; ok {
+		return lang
+	}
+
+	ext := filepath.Ext(base)
+	if ext != "" {
+		return languageByExtension[strings.ToLower(ext)]
+	}
+
+	return shebangLanguage(path)
+}
+
+// shebangLanguage classifies a script by the interpreter on its first line
+func shebangLanguage(path string) string {
+	file, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+
+	line, err := bufio.NewReader(file).ReadString('\n')
+	if err != nil && line == "" {
+		return ""
+	}
+	if !strings.HasPrefix(line, "#!") {
+		return ""
+	}
+
+	fields := strings.Fields(strings.TrimPrefix(line, "#!"))
+	if len(fields) == 0 {
+		return ""
+	}
+
+	interpreter := filepath.Base(fields[0])
+	if interpreter == "env" {
+		// "#!/usr/bin/env -S python3 -u" names the interpreter after options
+		interpreter = ""
+		for _, field := range fields[1:] {
+			if !strings.HasPrefix(field, "-") {
+				interpreter = filepath.Base(field)
+				break
+			}
+		}
+	}
+
+	return languageByInterpreter[interpreter]
+}
+
+// languageOverrides reads linguist-language attributes from .gitattributes
+// for the given repository-relative paths in a single git check-attr call
+func (ga *GitAnalyzer) languageOverrides(ctx context.Context, relPaths []string) (map[string]string, error) {
+	var input bytes.Buffer
+	for _, path := range relPaths {
+		input.WriteString(path)
+		input.WriteByte(0)
+	}
+
+	cmd := ga.gitCommand(ctx, "check-attr", "-z", "--stdin", "linguist-language")
+	cmd.Stdin = &input
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, err
+	}
+
+	// Output is a sequence of "<path> NUL <attribute> NUL <value> NUL"
+	overrides := make(map[string]string)
+	fields := strings.Split(string(output), "\x00")
+	for i := 0; i+2 < len(fields); i += 3 {
+		switch value := fields[i+2]; value {
+		case "unspecified", "unset", "set":
+		default:
+			overrides[fields[i]] = value
+		}
+	}
+
+	return overrides, nil
+}
+
+// filterByLanguage keeps the files classified as one of the --language
+// values. A linguist-language attribute in .gitattributes takes precedence
+// over the file's name, extension and shebang.
+func (ga *GitAnalyzer) filterByLanguage(ctx context.Context, files []string) []string {
+	wanted := make(map[string]bool, len(ga.config.Languages))
+	for _, lang := range ga.config.Languages {
+		wanted[normalizeLanguage(lang)] = true
+	}
+
+	relPaths := make([]string, len(files))
+	for i, file := range files {
+		rel, err := filepath.Rel(ga.config.Directory, file)
+		if err != nil {
+			rel = file
+		}
+		relPaths[i] = filepath.ToSlash(rel)
+	}
+
+	overrides, err := ga.languageOverrides(ctx, relPaths)
+	if err != nil {
+		ga.logWarn("Failed to read linguist-language attributes: %v", err)
+	}
+
+	kept := make([]string, 0, len(files))
+	for i, file := range files {
+		lang, ok := overrides[relPaths[i]]
+		if !ok {
+			lang = detectLanguage(file)
+		}
+		if wanted[normalizeLanguage(lang)] {
+			kept = append(kept, file)
+		}
+	}
+
+	ga.logDebug("Language filter kept %d of %d files", len(kept), len(files))
+
+	return kept
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"regexp"
+	"sync"
+	"time"
+)
+
+// indexLockError matches git's report of an index.lock held by another git
+// process, such as "fatal: Unable to create '/repo/.git/index.lock': File
+// exists."
+var indexLockError = regexp.MustCompile(`Unable to create '[^']*index\.lock'`)
+
+const (
+	lockBackoff    = 250 * time.Millisecond // first pause when the index is locked
+	lockMaxBackoff = 4 * time.Second        // longest pause, reached by doubling
+	lockAttempts   = 6                      // blame attempts per file before giving up
+)
+
+// isIndexLockError reports whether a git command failed because another
+// git process holds the index lock
+func isIndexLockError(err error) bool {
+	var exitErr *exec.ExitError
+	return errors.As(err, &exitErr) && indexLockError.Match(exitErr.Stderr)
+}
+
+// lockGate pauses the dispatch of new blame calls while another git process
+// holds the index lock, for --retry-on-lock. Every worker waits at the gate
+// before blaming a file, so when many blame calls fail on the lock at once
+// they share a single pause and resume together, instead of each retrying
+// its file on its own schedule. The pause doubles while the lock persists
+// and resets once a blame call succeeds.
+type lockGate struct {
+	mu     sync.Mutex
+	until  time.Time // dispatch resumes at this time
+	streak int       // consecutive pauses without a successful blame
+}
+
+// wait blocks until the gate is open or ctx is canceled
+func (g *lockGate) wait(ctx context.Context) error {
+	for {
+		g.mu.Lock()
+		delay := time.Until(g.until)
+		g.mu.Unlock()
+		if delay <= 0 {
+			return nil
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// hold closes the gate after a blame call failed on the lock, unless it's
+// already closed, and returns the length of the new pause, 0 when the
+// failure joined a pause already under way
+func (g *lockGate) hold() time.Duration {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if time.Now().Before(g.until) {
+		return 0
+	}
+
+	delay := min(lockBackoff<<g.streak, lockMaxBackoff)
+	g.streak++
+	g.until = time.Now().Add(delay)
+	return delay
+}
+
+// release records a successful blame call, so the next lock starts over
+// with the shortest pause
+func (g *lockGate) release() {
+	g.mu.Lock()
+	g.streak = 0
+	g.mu.Unlock()
+}
+
+// blameFile runs git blame on a file. With --retry-on-lock, files whose
+// blame failed because the index was locked are blamed again once the gate
+// reopens, up to lockAttempts times.
+func (ga *GitAnalyzer) blameFile(ctx context.Context, filePath string, gate *lockGate) BlameResult {
+	if gate == nil {
+		return ga.runGitBlame(ctx, filePath)
+	}
+
+	for attempt := 1; ; attempt++ {
+		if err := gate.wait(ctx); err != nil {
+			return BlameResult{FilePath: filePath, Error: err}
+		}
+
+		result := ga.runGitBlame(ctx, filePath)
+		if !isIndexLockError(result.Error) {
+			if result.Error == nil {
+				gate.release()
+			}
+			return result
+		}
+		if attempt == lockAttempts {
+			ga.logWarn("Giving up on %s: the git index stayed locked", result.FilePath)
+			return result
+		}
+		if delay := gate.hold(); delay > 0 {
+			ga.logWarn("The git index is locked by another git process; pausing blame for %v", delay)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Log formats for diagnostics on stderr
+const (
+	LogFormatText = "text"
+	LogFormatJSON = "json"
+)
+
+// newLogger creates the diagnostics logger. Without an explicit level,
+// --verbose enables debug messages and --quiet only lets errors through.
+func newLogger(config Config) *slog.Logger {
+	level := slog.LevelInfo
+	switch {
+	case config.LogLevel != "":
+		// Validated when the flags are parsed
+		level.UnmarshalText([]byte(config.LogLevel))
+	case config.Verbose:
+		level = slog.LevelDebug
+	case config.Quiet:
+		level = slog.LevelError
+	}
+
+	if config.LogFormat == LogFormatJSON {
+		return slog.New(slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{Level: level}))
+	}
+	return slog.New(&textHandler{w: os.Stderr, level: level, mu: &sync.Mutex{}})
+}
+
+// textHandler writes log records as "[LEVEL] message key=value" lines, with
+// warnings and errors styled like the rest of the output. Keys in groups are
+// prefixed with the group names, as in "group.key=value".
+type textHandler struct {
+	w      io.Writer
+	level  slog.Level
+	attrs  []slog.Attr // already prefixed with their groups
+	prefix string      // groups opened by WithGroup, e.g. "a.b."
+	mu     *sync.Mutex
+}
+
+func (h *textHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level
+}
+
+func (h *textHandler) Handle(_ context.Context, r slog.Record) error {
+	var b strings.Builder
+
+	switch {
+	case r.Level >= slog.LevelError:
+		b.WriteString(errorStyle.Render("[ERROR]"))
+	case r.Level >= slog.LevelWarn:
+		b.WriteString(warningStyle.Render("[WARN]"))
+	case r.Level >= slog.LevelInfo:
+		b.WriteString("[INFO]")
+	default:
+		b.WriteString("[DEBUG]")
+	}
+	b.WriteString(" ")
+	b.WriteString(r.Message)
+
+	for _, a := range h.attrs {
+		writeAttr(&b, "", a)
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		writeAttr(&b, h.prefix, a)
+		return true
+	})
+	b.WriteString("\n")
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := io.WriteString(h.w, b.String())
+	return err
+}
+
+// writeAttr writes an attribute as " key=value", expanding groups
+func writeAttr(b *strings.Builder, prefix string, a slog.Attr) {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return
+	}
+	if a.Value.Kind() == slog.KindGroup {
+		// Attributes of an unnamed group belong to the enclosing one
+		if a.Key != "" {
+			prefix += a.Key + "."
+		}
+		for _, attr := range a.Value.Group() {
+			writeAttr(b, prefix, attr)
+		}
+		return
+	}
+	fmt.Fprintf(b, " %s%s=%v", prefix, a.Key, a.Value)
+}
+
+func (h *textHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *h
+	clone.attrs = slices.Clone(h.attrs)
+	for _, a := range attrs {
+		// Fix the groups the attribute belongs to now
+		clone.attrs = append(clone.attrs, slog.Group(strings.TrimSuffix(h.prefix, "."), a))
+	}
+	return &clone
+}
+
+func (h *textHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	clone := *h
+	clone.prefix += name + "."
+	return &clone
+}
+
+// Logging methods
+func (ga *GitAnalyzer) logDebug(format string, args ...any) {
+	ga.logger.Debug(fmt.Sprintf(format, args...))
+}
+
+func (ga *GitAnalyzer) logInfo(format string, args ...any) {
+	ga.logger.Info(fmt.Sprintf(format, args...))
+}
+
+func (ga *GitAnalyzer) logWarn(format string, args ...any) {
+	ga.logger.Warn(fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,21 @@
+package main
+
+import (
+	"bytes"
+	"log/slog"
+	"sync"
+	"testing"
+)
+
+func TestTextHandlerGroups(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(&textHandler{w: &buf, level: slog.LevelInfo, mu: &sync.Mutex{}})
+
+	logger.With("run", 1).WithGroup("blame").With("file", "a.go").WithGroup("git").
+		Info("done", "exit", 0, slog.Group("time", "wall", "1s"))
+
+	want := "[INFO] done run=1 blame.file=a.go blame.git.exit=0 blame.git.time.wall=1s\n"
+	if buf.String() != want {
+		t.Errorf("got %q, want %q", buf.String(), want)
+	}
+}
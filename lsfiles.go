@@ -0,0 +1,129 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// trackedFile is an index entry listed by git ls-files
+type trackedFile struct {
+	path   string // relative to the analyzed directory, slash-separated
+	object string
+	size   int64
+}
+
+// listTrackedFiles lists the files tracked in the index under the analyzed
+// directory with git ls-files, which avoids a stat call per file on slow
+// filesystems. Blob sizes come from a single git cat-file call and are only
+// looked up when --max-file-size or LFS pointer detection needs them.
+// Untracked files are not listed, and .gitignore needn't be consulted since
+// ignored files aren't tracked.
+func (ga *GitAnalyzer) listTrackedFiles(ctx context.Context) ([]string, error) {
+	output, err := ga.gitCommand(ctx, "ls-files", "-z", "--stage").Output()
+	if err != nil {
+		return nil, fmt.Errorf("git ls-files: %w", err)
+	}
+
+	var entries []trackedFile
+	seen := make(map[string]bool)
+	for entry := range strings.SplitSeq(string(output), "\x00") {
+		// "<mode> <object> <stage>\t<path>"
+		meta, file, ok := strings.Cut(entry, "\t")
+		if !ok || seen[file] {
+			continue // conflicted files are listed once per stage
+		}
+		fields := strings.Fields(meta)
+		if len(fields) < 2 || fields[0] == "160000" {
+			continue // submodules have their own history
+		}
+		seen[file] = true
+		entries = append(entries, trackedFile{path: file, object: fields[1]})
+	}
+
+	if ga.config.MaxFileSize > 0 || !ga.config.IncludeLFS {
+		if err := ga.blobSizes(ctx, entries); err != nil {
+			return nil, err
+		}
+	}
+
+	vendored := make(map[string]string) // ecosystem by directory, "" if not vendored
+	files := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		relPath := filepath.FromSlash(entry.path)
+		if reason := ga.skippedDirReason(entry.path, vendored); reason != "" {
+			ga.explainFile(relPath, reason)
+			continue
+		}
+
+		fullPath := filepath.Join(ga.config.Directory, relPath)
+		if ga.keepFile(fullPath, relPath, entry.size) {
+			files = append(files, fullPath)
+		}
+	}
+
+	ga.logDebug("git ls-files listed %d tracked files", len(entries))
+
+	return files, nil
+}
+
+// blobSizes fills in the size of each entry's blob
+func (ga *GitAnalyzer) blobSizes(ctx context.Context, entries []trackedFile) error {
+	var input bytes.Buffer
+	for _, entry := range entries {
+		input.WriteString(entry.object)
+		input.WriteByte('\n')
+	}
+
+	cmd := ga.gitCommand(ctx, "cat-file", "--batch-check=%(objectsize)")
+	cmd.Stdin = &input
+	output, err := cmd.Output()
+	if err != nil {
+		return fmt.Errorf("git cat-file: %w", err)
+	}
+
+	// One line per object, in input order; missing objects report "<object> missing"
+	lines := strings.Split(strings.TrimSuffix(string(output), "\n"), "\n")
+	for i := range entries {
+		if i < len(lines) {
+			entries[i].size, _ = strconv.ParseInt(lines[i], 10, 64)
+		}
+	}
+	return nil
+}
+
+// skippedDirReason explains why a tracked file lies in a directory the walk
+// would not descend into: a skipped directory name or, with
+// --exclude-vendored, a dependency directory. It returns "" for files in
+// other directories. Vendoring decisions are cached per directory.
+func (ga *GitAnalyzer) skippedDirReason(file string, vendored map[string]string) string {
+	dir := path.Dir(file)
+	for _, name := range strings.Split(dir, "/") {
+		if slices.Contains(skippedDirs, name) {
+			return "in skipped directory " + name
+		}
+	}
+
+	if !ga.config.ExcludeVendor {
+		return ""
+	}
+	for ; dir != "."; dir = path.Dir(dir) {
+		ecosystem, ok := vendored[dir]
+		if !ok {
+			ecosystem, _ = isVendoredDir(filepath.Join(ga.config.Directory, filepath.FromSlash(dir)))
+			if ecosystem != "" {
+				ga.logDebug("Skipping vendored %s dependencies: %s", ecosystem, dir)
+			}
+			vendored[dir] = ecosystem
+		}
+		if ecosystem != "" {
+			return "in vendored " + ecosystem + " dependencies " + dir
+		}
+	}
+	return ""
+}
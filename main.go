@@ -2,21 +2,32 @@ package main
 
 import (
 	"bufio"
+	"bytes"
 	"context"
 	"encoding/csv"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"hash/fnv"
+	"io"
+	"log/slog"
+	"net/http"
 	"os"
 	"os/exec"
 	"os/signal"
 	"path/filepath"
+	"reflect"
+	"regexp"
 	"runtime"
 	"slices"
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/charmbracelet/lipgloss"
 	"github.com/olekukonko/tablewriter"
@@ -24,14 +35,26 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"golang.org/x/sync/errgroup"
+	"golang.org/x/term"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
 )
 
 // Version and build info - set via ldflags
 var (
 	Version   = "dev"
 	GitCommit = "unknown"
+	BuildDate = "unknown"
 )
 
+// BuildInfo describes the running binary
+type BuildInfo struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	GoVersion string `json:"go_version"`
+	Built     string `json:"built"`
+}
+
 const (
 	AppName     = "Gala"
 	Description = "A high-performance command-line tool for analyzing git repository contributions by counting lines authored by different contributors."
@@ -41,12 +64,71 @@ const (
 type OutputFormat string
 
 const (
-	FormatTable OutputFormat = "table"
-	FormatJSON  OutputFormat = "json"
-	FormatCSV   OutputFormat = "csv"
-	FormatPlain OutputFormat = "plain"
+	FormatTable  OutputFormat = "table"
+	FormatJSON   OutputFormat = "json"
+	FormatCSV    OutputFormat = "csv"
+	FormatPlain  OutputFormat = "plain"
+	FormatDot    OutputFormat = "dot"
+	FormatInflux OutputFormat = "influx"
+	FormatJSONL  OutputFormat = "jsonl"
+)
+
+// ErrorFormat represents how errors are reported
+type ErrorFormat string
+
+const (
+	ErrorFormatText ErrorFormat = "text"
+	ErrorFormatJSON ErrorFormat = "json"
+)
+
+// Exit codes
+const (
+	ExitCodeError = 1
+	ExitCodeUsage = 2
+	ExitCodeGit   = 3 // git is missing or unusable
 )
 
+// ExitError associates an error with the process exit code it should produce
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+func (e *ExitError) Error() string { return e.Err.Error() }
+func (e *ExitError) Unwrap() error { return e.Err }
+
+// usageErrorf reports an invalid flag value or combination of flags, which
+// exits with ExitCodeUsage
+func usageErrorf(format string, args ...any) error {
+	return &ExitError{Code: ExitCodeUsage, Err: fmt.Errorf(format, args...)}
+}
+
+// exitCode returns the process exit code for an error
+func exitCode(err error) int {
+	var exitErr *ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.Code
+	}
+	return ExitCodeError
+}
+
+// ErrorReport is the machine-readable error emitted by --error-format json
+type ErrorReport struct {
+	Error string `json:"error"`
+	Code  int    `json:"code,omitempty"`
+}
+
+// reportError prints an error to stderr, so it never mixes with results on
+// stdout: as an ErrorReport with --error-format json, and otherwise through
+// the diagnostics logger, in the --log-format of other messages
+func reportError(config Config, err error, code int) {
+	if config.ErrorFormat == ErrorFormatJSON {
+		json.NewEncoder(os.Stderr).Encode(ErrorReport{Error: err.Error(), Code: code})
+		return
+	}
+	newLogger(config).Error(err.Error())
+}
+
 // SortBy represents different sorting options
 type SortBy string
 
@@ -54,55 +136,260 @@ const (
 	SortByLines SortBy = "lines"
 	SortByName  SortBy = "name"
 	SortByFiles SortBy = "files"
+	SortByScore SortBy = "score"
+)
+
+// RankStyle represents how ranks are decorated in table output
+type RankStyle string
+
+const (
+	RankNone    RankStyle = "none"
+	RankMedals  RankStyle = "medals"
+	RankNumeric RankStyle = "numeric"
+	RankCustom  RankStyle = "custom"
+)
+
+// Named delimiters for plain output; any other value is used literally
+const (
+	PlainTab     = "tab"
+	PlainSpace   = "space"
+	PlainAligned = "aligned" // pad columns to a common width
+)
+
+// CreditMode selects whose identity blamed lines are credited to
+type CreditMode string
+
+const (
+	CreditAuthor    CreditMode = "author"
+	CreditCommitter CreditMode = "committer"
+)
+
+// TimeBasis selects which of a commit's dates a blamed line is dated by
+type TimeBasis string
+
+const (
+	TimeAuthor    TimeBasis = "author"    // when the change was written
+	TimeCommitter TimeBasis = "committer" // when the change landed
 )
 
+// terminalLimit is how many authors the table shows on a terminal when
+// --limit isn't given
+const terminalLimit = 50
+
+// defaultMedals decorates the top 3 ranks in medals style
+var defaultMedals = []string{"🥇", "🥈", "🥉"}
+
 // Config holds application configuration
 type Config struct {
-	Directory     string
-	Username      string
-	Concurrency   int
-	OutputFormat  OutputFormat
-	SortBy        SortBy
-	MinLines      int
-	MaxResults    int
-	IncludeEmoji  bool
-	Quiet         bool
-	Verbose       bool
-	NoProgress    bool
-	ExcludeAuthor []string
-	IncludeAuthor []string
-	DateSince     string
-	DateUntil     string
-	ExtraPatterns []string
-	ConfigFile    string
+	Directory         string
+	Username          string
+	Concurrency       int
+	OutputFormat      OutputFormat
+	SortBy            SortBy
+	MinLines          int
+	MinFiles          int
+	MaxResults        int
+	IncludeEmoji      bool
+	Quiet             bool
+	Verbose           bool
+	NoProgress        bool
+	ExcludeAuthor     []string
+	IncludeAuthor     []string
+	DateSince         string
+	DateUntil         string
+	ExtraPatterns     []string
+	ConfigFile        string
+	Locale            string
+	ExcludeVendor     bool
+	ProgressJSON      bool
+	MaxFileSize       int64
+	DiffBase          string
+	RankStyle         RankStyle
+	RankSymbols       []string
+	NoTrimTotal       bool
+	ErrorFormat       ErrorFormat
+	ShowPercentile    bool
+	Resume            bool
+	CSVBOM            bool
+	CSVDelimiter      rune
+	NoOthers          bool
+	GitPath           string
+	GitConfig         []string
+	Fields            []string
+	RecurseSubmodules bool
+	NoCheckpoint      bool // don't checkpoint for --resume, as in subcommands
+	Weights           []WeightRule
+	Weighted          bool
+	Teams             []Team
+	ByTeam            bool
+	FailOnShallow     bool
+	File              string
+	LineRange         string
+	ActiveSince       time.Time
+	ReadOnly          bool
+	DedupeLines       bool
+	Credit            CreditMode
+	PlainDelimiter    string
+	IncludeLFS        bool
+	MergeBots         bool
+	ExcludeBots       bool
+	BotPatterns       []string
+	Languages         []string
+	LogLevel          string
+	LogFormat         string
+	NoDefaultExcludes bool
+	Score             bool
+	ScoreWeights      ScoreWeights
+	ExcludePathRegex  []string
+	IncludePathRegex  []string
+	RecordCommands    bool
+	Sample            int
+	Seed              int64
+	NoIgnoreRevs      bool
+	ShowCommits       bool
+	AllBranches       bool
+	TableStyle        TableStyle
+	Labels            []string
+	Anonymize         bool
+	AnonymizeSalt     string
+	TextOnly          bool
+	FollowRenames     bool
+	UnknownLabel      string
+	ExcludeUnknown    bool
+	RichSummary       bool
+	TimeBasis         TimeBasis
+	DetectAliases     bool
+	WeightStrategies  []WeightStrategy
+	DecayHalfLife     float64 // days
+	AlsoWrite         string
+	AlsoWriteFormat   OutputFormat
+	UseGitLsFiles     bool
+	CountMode         CountMode
+	Explain           string // "-" for stderr
+	ResolveHandles    bool
+	HandlesFile       string
+	ShowAverage       bool
+	ChangedSince      string
+	CSVColumns        []string
+	ImplicitLimit     bool // MaxResults was set for a terminal, not by --limit
+	CreditMoves       MoveCredit
+	Ordered           bool
+	CommitsFrom       string // "-" for stdin
+	Precision         int    // decimal places of percentages, -1 for each format's default
+	OrphanThreshold   float64
+	MergeCase         bool // --merge-case-insensitive
+	Tiers             bool
+	FollowSymlinks    bool
+	TierThresholds    []float64 // core and occasional percentage thresholds
+	Window            string    // first-year, last-year, first-<days>d or last-<days>d
+	PartialResults    bool      // --partial-on-interrupt
+	AuthorFormat      string    // preset or template such as "{name} <{email}>"
+	RepoStats         bool
+	RetryOnLock       bool
+	ModifiedSince     time.Time // --modified-within cutoff, zero to keep every file
+	ModifiedMtime     bool      // --modified-by-mtime
+	DetailedJSON      bool
+	DirDepth          int    // directory levels of the --dir-depth rollup, 0 for none
+	DateLayout        string // --date-format layout, "" for YYYY-MM-DD
 }
 
 // AuthorStats represents statistics for an author
 type AuthorStats struct {
-	Name        string  `json:"name"`
-	LineCount   int     `json:"line_count"`
-	FileCount   int     `json:"file_count"`
-	FirstCommit string  `json:"first_commit,omitempty"`
-	LastCommit  string  `json:"last_commit,omitempty"`
-	Percentage  float64 `json:"percentage"`
+	Name          string  `json:"name"`
+	LineCount     int     `json:"line_count"`
+	FileCount     int     `json:"file_count"`
+	LinesPerFile  float64 `json:"lines_per_file"`         // high for deep ownership of few files, low for broad touches
+	CommitCount   int     `json:"commit_count,omitempty"` // distinct commits behind the lines, with --show-commits
+	FirstCommit   string  `json:"first_commit,omitempty"`
+	LastCommit    string  `json:"last_commit,omitempty"`
+	Percentage    float64 `json:"percentage"`
+	Percentile    float64 `json:"percentile"` // "top N%" rank among all contributors by lines
+	Rank          int     `json:"rank"`       // position by lines (or score), kept when sorted differently
+	WeightedLines float64 `json:"weighted_lines,omitempty"`
+	Score         float64 `json:"score,omitempty"`
+	GitHubHandle  string  `json:"github_handle,omitempty"` // with --resolve-handles, when one can be derived
+	Email         string  `json:"email,omitempty"`         // with an --author-format showing emails
+
+	// Files lists the files the author owns lines in, with --detailed-json
+	Files []AuthorFile `json:"files,omitempty"`
 }
 
-// FileContribution represents a file contribution by a user
-type FileContribution struct {
+// AuthorFile is a file an author owns lines in, listed with --detailed-json
+type AuthorFile struct {
 	Path      string `json:"path"`
 	LineCount int    `json:"line_count"`
 }
 
+// FileContribution represents a file contribution by a user
+type FileContribution struct {
+	Path       string  `json:"path"`
+	LineCount  int     `json:"line_count"`
+	FileLines  int     `json:"file_lines"`            // lines in the file by any author
+	Share      float64 `json:"share"`                 // percentage of the user's lines found in this file
+	Ownership  float64 `json:"ownership"`             // percentage of the file's lines by the user
+	LinesAdded int     `json:"lines_added,omitempty"` // lines the user added over the file's history, with --follow-renames
+}
+
+// OthersStats aggregates the authors cut off by --limit
+type OthersStats struct {
+	Authors    int     `json:"authors"`
+	LineCount  int     `json:"line_count"`
+	FileCount  int     `json:"file_count"`
+	Percentage float64 `json:"percentage"`
+}
+
+// Label returns the display name of the aggregated row
+func (o *OthersStats) Label() string {
+	if o.Authors == 1 {
+		return "Others (1 author)"
+	}
+	return fmt.Sprintf("Others (%d authors)", o.Authors)
+}
+
 // AnalysisResult holds the results of git analysis
 type AnalysisResult struct {
-	Authors           []AuthorStats      `json:"authors"`
-	UserContributions []FileContribution `json:"user_contributions,omitempty"`
-	TotalLines        int                `json:"total_lines"`
-	FilesProcessed    int                `json:"files_processed"`
-	TotalFiles        int                `json:"total_files"`
-	ProcessingTime    time.Duration      `json:"processing_time"`
-	Repository        string             `json:"repository"`
-	GeneratedAt       time.Time          `json:"generated_at"`
+	SchemaVersion       int                `json:"schema_version"`
+	Authors             []AuthorStats      `json:"authors"`
+	UserContributions   []FileContribution `json:"user_contributions,omitempty"`
+	TotalLines          int                `json:"total_lines"`
+	FilesProcessed      int                `json:"files_processed"`
+	TotalFiles          int                `json:"total_files"`
+	ProcessingTime      time.Duration      `json:"processing_time"`
+	Repository          string             `json:"repository"`
+	GeneratedAt         time.Time          `json:"generated_at"`
+	SkippedLarge        int                `json:"skipped_large_files,omitempty"`
+	SkippedLFS          int                `json:"skipped_lfs_files,omitempty"`
+	SkippedBinary       int                `json:"skipped_binary_files,omitempty"`
+	SkippedUntracked    int                `json:"skipped_untracked_files,omitempty"`
+	SkippedStale        int                `json:"skipped_stale_files,omitempty"`
+	UnfilteredLines     int                `json:"unfiltered_lines,omitempty"`
+	FilterTotals        *FilterTotals      `json:"filter_totals,omitempty"`
+	Others              *OthersStats       `json:"others,omitempty"`
+	Teams               []TeamStats        `json:"teams,omitempty"`
+	Tiers               []TierStats        `json:"tiers,omitempty"`
+	CoOwnership         []CoOwnership      `json:"co_ownership,omitempty"`
+	Submodules          []SubmoduleResult  `json:"submodules,omitempty"`
+	Shallow             bool               `json:"shallow,omitempty"`
+	Sampled             bool               `json:"sampled,omitempty"`
+	Partial             bool               `json:"partial,omitempty"` // interrupted before every file was processed
+	SampleSize          int                `json:"sample_size,omitempty"`
+	EstimatedTotalLines int                `json:"estimated_total_lines,omitempty"`
+	GitCommands         []GitCommand       `json:"git_commands,omitempty"`
+	Head                *CommitRef         `json:"head,omitempty"`
+	Highlights          *Highlights        `json:"highlights,omitempty"`
+	DiffBase            string             `json:"diff_base,omitempty"`
+	DiffFiles           []DiffFile         `json:"diff_files,omitempty"`
+	Branches            []string           `json:"branches,omitempty"`
+	File                string             `json:"file,omitempty"`
+	LineRange           string             `json:"lines,omitempty"`
+	History             []HistoryStats     `json:"history,omitempty"`
+	FormerPaths         []string           `json:"former_paths,omitempty"`
+	Aliases             []AliasGroup       `json:"alias_suggestions,omitempty"`
+	OrphanedFiles       []OrphanedFile     `json:"orphaned_files,omitempty"`
+	RepoStats           *RepoStats         `json:"repo_stats,omitempty"`
+	Metrics             *Metrics           `json:"metrics,omitempty"`
+	Directories         []DirectoryStats   `json:"directories,omitempty"`
+	AuthorsFound        int                `json:"-"` // authors before --limit
 }
 
 // Styles for consistent UI
@@ -129,17 +416,148 @@ var (
 
 // GitAnalyzer handles git repository analysis
 type GitAnalyzer struct {
-	config          Config
-	excludePatterns []string
-	gitignoreGlobs  []string
+	config           Config
+	excludePatterns  []string
+	gitignoreGlobs   []string
+	submodules       []string
+	authorLabels     map[string]string // --author-format labels by author name
+	diffCommit       string            // --diff base, resolved to a commit hash
+	printer          *message.Printer
+	skippedLarge     int
+	skippedLFS       int
+	skippedBinary    int
+	skippedUntracked int
+	skippedStale     int
+	pathFiltered     []foundFile // files excluded by the path filters, counted once listed
+	filteredFiles    int         // of pathFiltered, the tracked ones the other checks keep
+	filteredLines    int
+	botPatterns      []*regexp.Regexp
+	excludePathRegex []*regexp.Regexp
+	includePathRegex []*regexp.Regexp
+	commands         *commandRecorder   // nil unless --record-commands
+	ignoreRevs       []string           // commits listed in .git-blame-ignore-revs
+	gitVersion       [2]int             // major and minor version, zero if unrecognized
+	repoRoot         string             // top level of the working tree
+	repoPrefix       string             // analyzed directory relative to repoRoot, "" at the top level
+	nameCasings      map[string]string  // display names by lowercased name, nil unless merging case
+	window           *timeWindow        // --window line dates, nil to count every line
+	labels           []Label            // --label dimensions of time-series output
+	importance       map[string]float64 // file-importance weights by relative path
+	explain          io.Writer          // --explain trace, nil when off
+	handles          map[string]string  // GitHub handles by lowercased email or name
+	commitSet        map[string]bool    // --commits-from hashes, nil to count every commit
+	logger           *slog.Logger
+}
+
+// flagDefaults returns the default of every command-line flag. The flags
+// are registered with these values and defaultConfig starts from them, so
+// the two can't drift apart.
+func flagDefaults() Config {
+	return Config{
+		OutputFormat:   FormatTable,
+		SortBy:         SortByLines,
+		Precision:      defaultPrecision,
+		TableStyle:     TableDefault,
+		PlainDelimiter: PlainTab,
+		CSVDelimiter:   ',',
+		DecayHalfLife:  defaultDecayHalfLife,
+		TierThresholds: slices.Clone(defaultTierThresholds),
+		AuthorFormat:   "name",
+		CreditMoves:    MovesOriginal,
+		CountMode:      CountPhysical,
+		Credit:         CreditAuthor,
+		TimeBasis:      TimeAuthor,
+		MinLines:       1,
+		UnknownLabel:   "Unknown",
+		LogFormat:      LogFormatText,
+		GitPath:        "git",
+		ErrorFormat:    ErrorFormatText,
+	}
+}
+
+// defaultConfig returns a quiet JSON analysis of the repository, with the
+// same defaults as the command-line flags. Subcommands start from it; their
+// analyses aren't resumable, so they don't checkpoint.
+func defaultConfig(repo string) Config {
+	config := flagDefaults()
+	config.Directory = repo
+	config.OutputFormat = FormatJSON
+	config.Quiet = true
+	config.NoProgress = true
+	config.NoCheckpoint = true
+	return config
 }
 
 // NewGitAnalyzer creates a new GitAnalyzer instance
 func NewGitAnalyzer(config Config) *GitAnalyzer {
+	tag, err := resolveLocale(config.Locale)
+	if err != nil {
+		tag = language.English
+	}
+
+	// Patterns are validated when the flags are parsed
+	botPatterns, _ := compileBotPatterns(config.BotPatterns)
+	excludePathRegex, _ := compileRegexes(config.ExcludePathRegex)
+	includePathRegex, _ := compileRegexes(config.IncludePathRegex)
+	labels, _ := parseLabels(config.Labels)
+
+	excludePatterns := getDefaultExcludePatterns()
+	if config.NoDefaultExcludes {
+		excludePatterns = nil
+	}
+
+	var commands *commandRecorder
+	if config.RecordCommands {
+		commands = newCommandRecorder()
+	}
+
 	return &GitAnalyzer{
-		config:          config,
-		excludePatterns: getDefaultExcludePatterns(),
+		commands:         commands,
+		config:           config,
+		excludePatterns:  excludePatterns,
+		botPatterns:      botPatterns,
+		labels:           labels,
+		excludePathRegex: excludePathRegex,
+		includePathRegex: includePathRegex,
+		printer:          message.NewPrinter(tag),
+		logger:           newLogger(config),
+	}
+}
+
+// resolveLocale determines the locale used for number formatting. An empty
+// name falls back to the LC_ALL, LC_NUMERIC and LANG environment variables.
+func resolveLocale(name string) (language.Tag, error) {
+	if name == "" {
+		for _, env := range []string{"LC_ALL", "LC_NUMERIC", "LANG"} {
+			if value := os.Getenv(env); value != "" {
+				if tag, err := parseLocale(value); err == nil {
+					return tag, nil
+				}
+				break
+			}
+		}
+		return language.English, nil
+	}
+
+	tag, err := parseLocale(name)
+	if err != nil {
+		return language.English, fmt.Errorf("invalid locale %q: %w", name, err)
+	}
+	return tag, nil
+}
+
+// parseLocale parses POSIX (de_DE.UTF-8) and BCP 47 (de-DE) locale names
+func parseLocale(name string) (language.Tag, error) {
+	// Strip encoding and modifier suffixes, e.g. "de_DE.UTF-8@euro"
+	if i := strings.IndexAny(name, ".@"); i >= 0 {
+		name = name[:i]
 	}
+
+	if name == "C" || name == "POSIX" {
+		return language.English, nil
+	}
+
+	return language.Parse(strings.ReplaceAll(name, "_", "-"))
 }
 
 // getDefaultExcludePatterns returns default file patterns to exclude
@@ -178,8 +596,55 @@ func getDefaultExcludePatterns() []string {
 	}
 }
 
-// validateDirectory checks if the directory exists and is a git repository
-func (ga *GitAnalyzer) validateDirectory() error {
+// vendoredDir describes a dependency directory installed by a package
+// manager. The directory is only treated as vendored when one of the
+// manifests exists next to it, so a hand-written "target/" in a project
+// without a Cargo.toml is still analyzed. A rule without manifests matches
+// on the directory name alone.
+type vendoredDir struct {
+	Ecosystem string
+	Dir       string
+	Manifests []string
+}
+
+// vendoredDirs lists the dependency directories skipped by --exclude-vendored.
+// vendor/ and node_modules/ aren't listed, as skippedDirs always skips them.
+var vendoredDirs = []vendoredDir{
+	{Ecosystem: "node", Dir: "bower_components", Manifests: []string{"bower.json"}},
+	{Ecosystem: "rust", Dir: "target", Manifests: []string{"Cargo.toml"}},
+	{Ecosystem: "java", Dir: "target", Manifests: []string{"pom.xml"}},
+	{Ecosystem: "python", Dir: ".venv", Manifests: []string{"pyproject.toml", "requirements.txt", "setup.py", "Pipfile"}},
+	{Ecosystem: "python", Dir: "venv", Manifests: []string{"pyproject.toml", "requirements.txt", "setup.py", "Pipfile"}},
+	{Ecosystem: "python", Dir: "site-packages"},
+}
+
+// isVendoredDir reports whether path is a dependency directory of an
+// ecosystem whose manifest is present in the parent directory
+func isVendoredDir(path string) (string, bool) {
+	name := filepath.Base(path)
+	parent := filepath.Dir(path)
+
+	for _, rule := range vendoredDirs {
+		if rule.Dir != name {
+			continue
+		}
+		if len(rule.Manifests) == 0 {
+			return rule.Ecosystem, true
+		}
+		for _, manifest := range rule.Manifests {
+			if _, err := os.Stat(filepath.Join(parent, manifest)); err == nil {
+				return rule.Ecosystem, true
+			}
+		}
+	}
+
+	return "", false
+}
+
+// validateDirectory checks if the directory exists and lies inside a git
+// working tree, and locates the top level of the tree. The directory may be
+// a subdirectory of the repository or a linked worktree.
+func (ga *GitAnalyzer) validateDirectory(ctx context.Context) error {
 	info, err := os.Stat(ga.config.Directory)
 	if err != nil {
 		return fmt.Errorf("directory %q does not exist", ga.config.Directory)
@@ -189,21 +654,63 @@ func (ga *GitAnalyzer) validateDirectory() error {
 		return fmt.Errorf("%q is not a directory", ga.config.Directory)
 	}
 
-	gitDir := filepath.Join(ga.config.Directory, ".git")
-	if _, err := os.Stat(gitDir); err != nil {
+	// Prints the top level and the directory's path below it, "" at the top
+	output, err := ga.gitCommand(ctx, "rev-parse", "--show-toplevel", "--show-prefix").Output()
+	if err != nil {
 		return fmt.Errorf("%q is not a git repository", ga.config.Directory)
 	}
+	root, prefix, _ := strings.Cut(strings.TrimSuffix(string(output), "\n"), "\n")
+	if root == "" {
+		return fmt.Errorf("%q is not inside a git working tree", ga.config.Directory)
+	}
+
+	ga.repoRoot = filepath.FromSlash(root)
+	ga.repoPrefix = strings.TrimSuffix(prefix, "/")
+	if ga.repoPrefix != "" {
+		ga.logDebug("Analyzing %s of the repository at %s", ga.repoPrefix, ga.repoRoot)
+	}
 
 	return nil
 }
 
-// loadGitignorePatterns loads patterns from .gitignore file
+// loadGitignorePatterns loads patterns from the .gitignore files of the
+// analyzed directory and of each directory above it up to the top level of
+// the repository
 func (ga *GitAnalyzer) loadGitignorePatterns() error {
-	gitignorePath := filepath.Join(ga.config.Directory, ".gitignore")
+	var patterns []string
+
+	dir, below := ga.repoRoot, ga.repoPrefix
+	for {
+		loaded, err := readGitignore(filepath.Join(dir, ".gitignore"), below)
+		if err != nil {
+			return err
+		}
+		patterns = append(patterns, loaded...)
+
+		if below == "" {
+			break
+		}
+		next, rest, _ := strings.Cut(below, "/")
+		dir, below = filepath.Join(dir, next), rest
+	}
+
+	ga.gitignoreGlobs = patterns
+	if len(patterns) > 0 {
+		ga.logDebug("Loaded %d patterns from .gitignore", len(patterns))
+	}
+
+	return nil
+}
 
-	file, err := os.Open(gitignorePath)
+// readGitignore reads the patterns of a .gitignore file in the analyzed
+// directory or above it, below being the slash-separated path from the
+// file's directory down to the analyzed one. Patterns containing a slash are
+// rewritten relative to the analyzed directory, or dropped when they can't
+// match inside it.
+func readGitignore(path, below string) ([]string, error) {
+	file, err := os.Open(path)
 	if err != nil {
-		return nil // .gitignore doesn't exist, that's okay
+		return nil, nil // .gitignore doesn't exist, that's okay
 	}
 	defer file.Close()
 
@@ -226,208 +733,865 @@ func (ga *GitAnalyzer) loadGitignorePatterns() error {
 			pattern = strings.TrimSuffix(pattern, "/")
 		}
 
+		// Patterns with a slash are relative to the .gitignore's directory
+		if below != "" && strings.Contains(pattern, "/") {
+			var ok bool
+			pattern, ok = strings.CutPrefix(strings.TrimPrefix(pattern, "/"), below+"/")
+			if !ok {
+				continue
+			}
+		}
+
 		patterns = append(patterns, pattern)
 	}
 
-	ga.gitignoreGlobs = patterns
-	if len(patterns) > 0 && ga.config.Verbose {
-		ga.logInfo("Loaded %d patterns from .gitignore", len(patterns))
+	return patterns, scanner.Err()
+}
+
+// compileRegexes compiles regular expressions, reporting the first invalid one
+func compileRegexes(patterns []string) ([]*regexp.Regexp, error) {
+	compiled := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("pattern %q: %w", pattern, err)
+		}
+		compiled = append(compiled, re)
 	}
+	return compiled, nil
+}
 
-	return scanner.Err()
+// matchesAny reports whether s matches any of the regular expressions
+func matchesAny(regexes []*regexp.Regexp, s string) bool {
+	for _, re := range regexes {
+		if re.MatchString(s) {
+			return true
+		}
+	}
+	return false
 }
 
 // shouldExcludeFile checks if a file should be excluded based on patterns
 func (ga *GitAnalyzer) shouldExcludeFile(filePath string) bool {
-	fileName := filepath.Base(filePath)
+	reason, _ := ga.exclusionReason(filePath)
+	return reason != ""
+}
+
+// exclusionReason names the pattern excluding a file, or returns "" when no
+// pattern excludes it. pathFilter reports whether the pattern is one of the
+// user's path filters rather than a default or .gitignore pattern.
+func (ga *GitAnalyzer) exclusionReason(filePath string) (reason string, pathFilter bool) {
+	matches := func(pattern string) bool {
+		return matchesGlob(pattern, filePath)
+	}
+
+	// Check path regexes against the slash-separated relative path
+	if len(ga.includePathRegex) > 0 || len(ga.excludePathRegex) > 0 {
+		slashPath := filepath.ToSlash(filePath)
+		if len(ga.includePathRegex) > 0 && !matchesAny(ga.includePathRegex, slashPath) {
+			return "matches no --include-path-regex", true
+		}
+		for _, re := range ga.excludePathRegex {
+			if re.MatchString(slashPath) {
+				return "--exclude-path-regex " + re.String(), true
+			}
+		}
+	}
 
 	// Check default exclude patterns
 	for _, pattern := range ga.excludePatterns {
-		if matched, _ := filepath.Match(pattern, fileName); matched {
-			return true
-		}
-		if matched, _ := filepath.Match(pattern, filePath); matched {
-			return true
+		if matches(pattern) {
+			return "default pattern " + pattern, false
 		}
 	}
 
 	// Check extra patterns from config
 	for _, pattern := range ga.config.ExtraPatterns {
-		if matched, _ := filepath.Match(pattern, fileName); matched {
-			return true
-		}
-		if matched, _ := filepath.Match(pattern, filePath); matched {
-			return true
+		if matches(pattern) {
+			return "exclude pattern " + pattern, true
 		}
 	}
 
 	// Check gitignore patterns
 	for _, pattern := range ga.gitignoreGlobs {
-		if matched, _ := filepath.Match(pattern, fileName); matched {
-			return true
-		}
-		if matched, _ := filepath.Match(pattern, filePath); matched {
-			return true
-		}
-		if strings.Contains(filePath, pattern) {
-			return true
+		if matches(pattern) || strings.Contains(filePath, pattern) {
+			return ".gitignore pattern " + pattern, false
 		}
 	}
 
-	return false
+	return "", false
+}
+
+// matchesGlob reports whether a glob pattern matches a file's name or its
+// relative path
+func matchesGlob(pattern, filePath string) bool {
+	if matched, _ := filepath.Match(pattern, filepath.Base(filePath)); matched {
+		return true
+	}
+	matched, _ := filepath.Match(pattern, filePath)
+	return matched
 }
 
 // findFiles finds all files to analyze
-func (ga *GitAnalyzer) findFiles() ([]string, error) {
+func (ga *GitAnalyzer) findFiles(ctx context.Context) ([]string, error) {
+	if ga.config.UseGitLsFiles {
+		files, err := ga.listTrackedFiles(ctx)
+		if err == nil {
+			ga.countFiltered(nil)
+			return ga.skipStale(ctx, files), nil
+		}
+		ga.logWarn("Failed to list tracked files, walking the directory instead: %v", err)
+	}
+
 	var files []string
+	var links []string         // symlinked directories to follow
+	found := map[string]bool{} // files, when following links may find them twice
 
-	err := filepath.Walk(ga.config.Directory, func(path string, info os.FileInfo, err error) error {
+	visit := func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return nil
 		}
 
-		if info.IsDir() {
-			dirName := filepath.Base(path)
-			skipDirs := []string{
-				".git", "node_modules", "vendor", ".cache", "__pycache__",
-				".vscode", ".idea", ".vs", "dist", "build", ".next", ".nuxt",
-			}
-			if slices.Contains(skipDirs, dirName) {
-				return filepath.SkipDir
+		relPath, err := filepath.Rel(ga.config.Directory, path)
+		if err != nil {
+			return nil
+		}
+
+		if isSymlinkedDir(path, info) {
+			if ga.config.FollowSymlinks {
+				links = append(links, path)
+			} else {
+				ga.logDebug("Skipping symlinked directory: %s", relPath)
+				ga.explainDir(relPath, "symlinked directory")
 			}
 			return nil
 		}
 
-		relPath, err := filepath.Rel(ga.config.Directory, path)
-		if err != nil {
+		if info.IsDir() {
+			if slices.Contains(skippedDirs, filepath.Base(path)) {
+				ga.explainDir(relPath, "skipped directory name")
+				return filepath.SkipDir
+			}
+			if path != ga.config.Directory && isNestedRepository(path) {
+				// Submodules and nested repositories have their own history
+				ga.logDebug("Skipping nested repository: %s", path)
+				ga.explainDir(relPath, "nested repository")
+				return filepath.SkipDir
+			}
+			if ga.config.ExcludeVendor && path != ga.config.Directory {
+				if ecosystem, ok := isVendoredDir(path); ok {
+					ga.logDebug("Skipping vendored %s dependencies: %s", ecosystem, path)
+					ga.explainDir(relPath, "vendored "+ecosystem+" dependencies")
+					return filepath.SkipDir
+				}
+			}
 			return nil
 		}
 
-		if !ga.shouldExcludeFile(relPath) {
+		if !found[path] && ga.keepFile(path, relPath, info.Size()) {
 			files = append(files, path)
+			found[path] = true
 		}
-
 		return nil
-	})
+	}
 
-	return files, err
-}
+	err := filepath.Walk(ga.config.Directory, visit)
 
-// BlameResult represents the result of git blame for a file
-type BlameResult struct {
-	FilePath string
-	Authors  []string
-	Error    error
-}
+	// Directories behind links are walked under their real paths, once each
+	visited := map[string]bool{ga.config.Directory: true}
+	for len(links) > 0 && err == nil {
+		dir, ok := ga.followLink(links[0])
+		links = links[1:]
+		if ok && !visited[dir] {
+			visited[dir] = true
+			err = filepath.Walk(dir, visit)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
 
-// runGitBlame runs git blame on a single file
-func (ga *GitAnalyzer) runGitBlame(ctx context.Context, filePath string) BlameResult {
-	relPath, err := filepath.Rel(ga.config.Directory, filePath)
+	tracked, err := ga.trackedPaths(ctx)
 	if err != nil {
-		return BlameResult{FilePath: filePath, Error: err}
+		ga.logDebug("Failed to list tracked files, keeping untracked ones: %v", err)
 	}
+	ga.countFiltered(tracked)
+	return ga.skipStale(ctx, ga.skipUntracked(files, tracked)), nil
+}
 
-	args := []string{"blame", "-M", "-C", "-w", "--line-porcelain"}
+// skippedDirs are directory names never descended into
+var skippedDirs = []string{
+	".git", "node_modules", "vendor", ".cache", "__pycache__",
+	".vscode", ".idea", ".vs", "dist", "build", ".next", ".nuxt",
+}
 
-	// Add date filtering if specified
-	if ga.config.DateSince != "" {
-		args = append(args, "--since="+ga.config.DateSince)
-	}
-	if ga.config.DateUntil != "" {
-		args = append(args, "--until="+ga.config.DateUntil)
+// keepFile applies the exclude patterns and the size, LFS and content
+// checks to a found file, counting the files it skips
+func (ga *GitAnalyzer) keepFile(path, relPath string, size int64) bool {
+	if reason, pathFilter := ga.exclusionReason(relPath); reason != "" {
+		ga.explainFile(relPath, reason)
+		if pathFilter && ga.countsFiltered() {
+			ga.pathFiltered = append(ga.pathFiltered, foundFile{path: path, size: size})
+		}
+		return false
 	}
 
-	args = append(args, relPath)
+	if ga.config.MaxFileSize > 0 && size > ga.config.MaxFileSize {
+		ga.skippedLarge++
+		ga.logDebug("Skipping large file (%s): %s", formatSize(size), relPath)
+		ga.explainFile(relPath, "larger than --max-file-size: "+formatSize(size))
+		return false
+	}
 
-	cmd := exec.CommandContext(ctx, "git", args...)
-	cmd.Dir = ga.config.Directory
+	if !ga.config.IncludeLFS && isLFSPointer(path, size) {
+		ga.skippedLFS++
+		ga.logDebug("Skipping Git LFS pointer: %s", relPath)
+		ga.explainFile(relPath, "Git LFS pointer")
+		return false
+	}
 
-	output, err := cmd.Output()
-	if err != nil {
-		return BlameResult{FilePath: filePath, Error: err}
+	if ga.config.TextOnly && !isTextFile(path) {
+		ga.skippedBinary++
+		ga.logDebug("Skipping non-text file: %s", relPath)
+		ga.explainFile(relPath, "not text, with --text-only")
+		return false
 	}
 
-	authors := make([]string, 0)
-	lines := strings.SplitSeq(string(output), "\n")
+	ga.explainFile(relPath, "")
+	return true
+}
+
+// sizeUnits maps size suffixes to their multipliers, longest suffix first.
+// KB, MB and GB are decimal; the binary units may drop the "iB", as in 5M.
+var sizeUnits = []struct {
+	suffix     string
+	multiplier int64
+}{
+	{"KIB", 1 << 10}, {"MIB", 1 << 20}, {"GIB", 1 << 30},
+	{"KB", 1000}, {"MB", 1000 * 1000}, {"GB", 1000 * 1000 * 1000},
+	{"K", 1 << 10}, {"M", 1 << 20}, {"G", 1 << 30},
+	{"B", 1},
+}
+
+// parseSize parses human-readable sizes such as "512KB", "5MB" or "1GiB"
+func parseSize(value string) (int64, error) {
+	str := strings.ToUpper(strings.TrimSpace(value))
+	multiplier := int64(1)
 
-	for line := range lines {
-		if strings.HasPrefix(line, "author ") {
-			author := strings.TrimPrefix(line, "author ")
-			if author != "" && !ga.shouldExcludeAuthor(author) {
-				authors = append(authors, author)
-			}
+	for _, unit := range sizeUnits {
+		if strings.HasSuffix(str, unit.suffix) {
+			str = strings.TrimSpace(strings.TrimSuffix(str, unit.suffix))
+			multiplier = unit.multiplier
+			break
 		}
 	}
 
-	return BlameResult{FilePath: filePath, Authors: authors}
+	n, err := strconv.ParseFloat(str, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %q (expected e.g. 500KB, 5MB, 1GiB)", value)
+	}
+
+	return int64(n * float64(multiplier)), nil
 }
 
-// shouldExcludeAuthor checks if an author should be excluded
-func (ga *GitAnalyzer) shouldExcludeAuthor(author string) bool {
-	// Check exclude list
-	for _, excluded := range ga.config.ExcludeAuthor {
-		if strings.EqualFold(author, excluded) {
-			return true
-		}
+// parseDelimiter parses a CSV delimiter given as a character or a name
+func parseDelimiter(value string) (rune, error) {
+	switch strings.ToLower(value) {
+	case "comma":
+		return ',', nil
+	case "semicolon":
+		return ';', nil
+	case "tab", `\t`:
+		return '\t', nil
+	case "pipe":
+		return '|', nil
 	}
 
-	// Check include list (if specified, only include listed authors)
-	if len(ga.config.IncludeAuthor) > 0 {
-		included := false
-		for _, includedAuthor := range ga.config.IncludeAuthor {
-			if strings.EqualFold(author, includedAuthor) {
-				included = true
-				break
-			}
-		}
-		return !included
+	r, size := utf8.DecodeRuneInString(value)
+	if size == 0 || size != len(value) || r == '"' || r == '\r' || r == '\n' || r == utf8.RuneError {
+		return 0, fmt.Errorf("invalid delimiter %q (expected a single character, comma, semicolon, tab or pipe)", value)
 	}
+	return r, nil
+}
 
-	return false
+// isTerminal reports whether f is an interactive terminal
+func isTerminal(f *os.File) bool {
+	return term.IsTerminal(int(f.Fd()))
 }
 
-// processFiles processes files concurrently and returns analysis results
-func (ga *GitAnalyzer) processFiles(ctx context.Context, files []string) (*AnalysisResult, error) {
-	startTime := time.Now()
+// formatCommitDate formats a Unix author time as a YYYY-MM-DD date in UTC
+func formatCommitDate(t int64) string {
+	return time.Unix(t, 0).UTC().Format(time.DateOnly)
+}
 
-	concurrency := ga.config.Concurrency
-	if concurrency <= 0 {
-		concurrency = runtime.NumCPU() * 2
+// lfsPointerPrefix starts every Git LFS pointer file
+const lfsPointerPrefix = "version https://git-lfs.github.com/spec/v1"
+
+// lfsPointerMaxSize is the size limit of pointer files in the LFS spec
+const lfsPointerMaxSize = 1024
+
+// isLFSPointer reports whether the file is a Git LFS pointer rather than
+// content, which happens when the LFS objects weren't checked out
+func isLFSPointer(path string, size int64) bool {
+	if size < int64(len(lfsPointerPrefix)) || size > lfsPointerMaxSize {
+		return false
 	}
 
-	var bar *progressbar.ProgressBar
-	if !ga.config.NoProgress && !ga.config.Quiet {
-		bar = progressbar.NewOptions(len(files),
-			progressbar.OptionSetDescription("Processing files"),
-			progressbar.OptionSetTheme(progressbar.Theme{
-				Saucer:        "█",
-				SaucerPadding: "░",
-				BarStart:      "[",
-				BarEnd:        "]",
-			}),
-			progressbar.OptionShowCount(),
-			progressbar.OptionShowIts(),
-			progressbar.OptionSetWidth(50),
-		)
+	file, err := os.Open(path)
+	if err != nil {
+		return false
 	}
+	defer file.Close()
 
-	resultsChan := make(chan BlameResult, len(files))
-	g, ctx := errgroup.WithContext(ctx)
-	fileChan := make(chan string, len(files))
+	head := make([]byte, len(lfsPointerPrefix))
+	if _, err := io.ReadFull(file, head); err != nil {
+		return false
+	}
+	return string(head) == lfsPointerPrefix
+}
 
-	// Start workers
-	for i := 0; i < concurrency; i++ {
-		g.Go(func() error {
-			for filePath := range fileChan {
-				select {
-				case <-ctx.Done():
+// sniffLen is how much of a file content type detection looks at
+const sniffLen = 512
+
+// isTextFile sniffs the start of a file's content and reports whether it is
+// text, regardless of its name
+func isTextFile(path string) bool {
+	file, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+
+	head := make([]byte, sniffLen)
+	n, err := io.ReadFull(file, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return false
+	}
+	return strings.HasPrefix(http.DetectContentType(head[:n]), "text/")
+}
+
+// formatSize formats a byte count for display
+func formatSize(n int64) string {
+	switch {
+	case n >= 1<<30:
+		return fmt.Sprintf("%.1f GiB", float64(n)/(1<<30))
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MiB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KiB", float64(n)/(1<<10))
+	default:
+		return fmt.Sprintf("%d B", n)
+	}
+}
+
+// BlameResult represents the result of git blame for a file
+type BlameResult struct {
+	FilePath string                `json:"file"`
+	Authors  []string              `json:"authors"`
+	Emails   map[string]string     `json:"emails,omitempty"`   // email of each included author
+	Spans    map[string]authorSpan `json:"spans,omitempty"`    // author times of each included author's lines
+	Commits  map[string][]string   `json:"commits,omitempty"`  // distinct commits of each included author's lines, with --show-commits
+	Times    []int64               `json:"times,omitempty"`    // time of each line in Authors, with the recency-decay weight
+	Origins  []string              `json:"origins,omitempty"`  // origin of each line in Authors, with --credit-moves proportional
+	Excluded int                   `json:"excluded,omitempty"` // lines by authors removed by the author filters
+	Error    error                 `json:"-"`
+}
+
+// authorSpan is the range of author times, in Unix seconds, of an author's lines
+type authorSpan struct {
+	First int64 `json:"first"`
+	Last  int64 `json:"last"`
+}
+
+// extend widens the span to include t
+func (s authorSpan) extend(t int64) authorSpan {
+	if s.First == 0 || t < s.First {
+		s.First = t
+	}
+	if t > s.Last {
+		s.Last = t
+	}
+	return s
+}
+
+// isShallow reports whether the repository is a shallow clone, falling back
+// to checking for .git/shallow when git is too old to answer
+func (ga *GitAnalyzer) isShallow(ctx context.Context) bool {
+	output, err := ga.gitCommand(ctx, "rev-parse", "--is-shallow-repository").Output()
+	if err == nil {
+		return strings.TrimSpace(string(output)) == "true"
+	}
+
+	_, err = os.Stat(filepath.Join(ga.repoRoot, ".git", "shallow"))
+	return err == nil
+}
+
+// gitCommand builds a git command that runs in the analyzed directory, using
+// the configured git executable and -c configuration overrides
+func (ga *GitAnalyzer) gitCommand(ctx context.Context, args ...string) *exec.Cmd {
+	cmd := newGitCommand(ctx, ga.config, args...)
+	if ga.commands != nil {
+		ga.commands.record(cmd.Args)
+	}
+	return cmd
+}
+
+// newGitCommand builds a git command for a configuration, before or without
+// an analyzer: it runs the configured git binary in the analyzed directory,
+// with the --git-config overrides and, with --read-only, without optional
+// locks. Analyzers use gitCommand, which also records the command.
+func newGitCommand(ctx context.Context, config Config, args ...string) *exec.Cmd {
+	gitPath := config.GitPath
+	if gitPath == "" {
+		gitPath = "git"
+	}
+
+	fullArgs := make([]string, 0, len(config.GitConfig)*2+len(args))
+	for _, override := range config.GitConfig {
+		fullArgs = append(fullArgs, "-c", override)
+	}
+	fullArgs = append(fullArgs, args...)
+
+	cmd := exec.CommandContext(ctx, gitPath, fullArgs...)
+	cmd.Dir = config.Directory
+	if config.ReadOnly {
+		// Keep git from refreshing the index as a side effect of reading it
+		cmd.Env = append(os.Environ(), "GIT_OPTIONAL_LOCKS=0")
+	}
+	return cmd
+}
+
+// runGitBlame runs git blame on a single file
+func (ga *GitAnalyzer) runGitBlame(ctx context.Context, filePath string) BlameResult {
+	relPath, err := filepath.Rel(ga.config.Directory, filePath)
+	if err != nil {
+		return BlameResult{FilePath: filePath, Error: err}
+	}
+	if !ga.insideDirectory(filePath) {
+		return BlameResult{FilePath: filePath, Error: fmt.Errorf("%s is outside the analyzed directory", relPath)}
+	}
+
+	args := ga.blameArgs()
+
+	// Add date filtering if specified
+	if ga.config.DateSince != "" {
+		args = append(args, "--since="+ga.config.DateSince)
+	}
+	if ga.config.DateUntil != "" {
+		args = append(args, "--until="+ga.config.DateUntil)
+	}
+
+	args = append(args, "--", relPath)
+
+	output, err := ga.gitCommand(ctx, args...).Output()
+	if err != nil {
+		return BlameResult{FilePath: filePath, Error: err}
+	}
+
+	result := ga.parseBlame(output, filePath)
+	result.FilePath = filePath
+	return result
+}
+
+// blameLine is the commit metadata git blame reports for a single line.
+// Author and Email describe the credited identity, which is the commit's
+// author or committer depending on the parsed role. Time is the author or
+// committer date depending on the parsed time basis.
+type blameLine struct {
+	Author   string
+	Email    string
+	Commit   string
+	OrigLine int    // line number in the commit that introduced the line
+	OrigPath string // file name in the commit that introduced the line
+	Time     int64  // Unix seconds
+	Hash     uint64 // hash of the line's content
+	Content  string
+}
+
+// parsePorcelain parses --line-porcelain output structurally. Each line of
+// the file is reported as a header block, starting with the commit hash and
+// followed by "key value" fields, and terminated by the line's content
+// prefixed with a tab. Only header fields are interpreted, so file content
+// that looks like a header field can't be mistaken for one. The role selects
+// the "author" or "committer" identity fields, and the basis the time field.
+func parsePorcelain(output []byte, role CreditMode, basis TimeBasis) []blameLine {
+	var lines []blameLine
+	var current blameLine
+	inHeader := false
+
+	for line := range strings.SplitSeq(string(output), "\n") {
+		if strings.HasPrefix(line, "\t") {
+			if inHeader {
+				h := fnv.New64a()
+				h.Write([]byte(line[1:]))
+				current.Hash = h.Sum64()
+				current.Content = line[1:]
+				lines = append(lines, current)
+			}
+			inHeader = false
+			continue
+		}
+
+		// Header lines can end in "\r" when the output passed through CRLF
+		// conversion, which would leave author names that match nothing
+		line = strings.TrimRight(line, "\r")
+
+		if !inHeader {
+			if line == "" {
+				continue
+			}
+			// "<hash> <orig line> <final line> [<group size>]" starts a block
+			fields := strings.Fields(line)
+			current = blameLine{Commit: fields[0]}
+			if len(fields) > 1 {
+				current.OrigLine, _ = strconv.Atoi(fields[1])
+			}
+			inHeader = true
+			continue
+		}
+
+		key, value, _ := strings.Cut(line, " ")
+		switch key {
+		case string(role):
+			current.Author = decodeGitName(strings.TrimRightFunc(value, unicode.IsSpace))
+		case string(role) + "-mail":
+			current.Email = strings.TrimSuffix(strings.TrimPrefix(value, "<"), ">")
+		case string(basis) + "-time":
+			current.Time, _ = strconv.ParseInt(value, 10, 64)
+		case "filename":
+			current.OrigPath = value
+		}
+	}
+
+	return lines
+}
+
+// parseBlame extracts the author of every line from porcelain output,
+// collecting the included authors with their emails and author times, and
+// counting the lines by excluded authors
+func (ga *GitAnalyzer) parseBlame(output []byte, path string) BlameResult {
+	result := BlameResult{
+		Authors: make([]string, 0),
+		Emails:  make(map[string]string),
+		Spans:   make(map[string]authorSpan),
+	}
+
+	type authorLine struct {
+		author string
+		hash   uint64
+	}
+	seen := make(map[authorLine]bool)
+
+	type authorCommit struct {
+		author string
+		commit string
+	}
+	var commits map[authorCommit]bool
+	if ga.config.ShowCommits {
+		commits = make(map[authorCommit]bool)
+		result.Commits = make(map[string][]string)
+	}
+
+	role := ga.config.Credit
+	if role == "" {
+		role = CreditAuthor
+	}
+
+	code := ga.newCodeLineFilter(path)
+
+	for _, line := range parsePorcelain(output, role, ga.timeBasis()) {
+		if code != nil && !code.isCode(line.Content) {
+			continue
+		}
+		line.Author = ga.knownName(line.Author)
+		if ga.config.DedupeLines {
+			key := authorLine{line.Author, line.Hash}
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+		}
+		if ga.shouldExcludeAuthor(line.Author) || (ga.commitSet != nil && !ga.commitSet[line.Commit]) || !ga.inWindow(line.Time) {
+			result.Excluded++
+			continue
+		}
+		result.Authors = append(result.Authors, line.Author)
+		if ga.weighting(WeightRecencyDecay) {
+			result.Times = append(result.Times, line.Time)
+		}
+		if ga.config.CreditMoves == MovesProportional {
+			result.Origins = append(result.Origins, originKey(line))
+		}
+		if line.Email != "" {
+			result.Emails[line.Author] = line.Email
+		}
+		if line.Time > 0 {
+			result.Spans[line.Author] = result.Spans[line.Author].extend(line.Time)
+		}
+		if commits != nil && line.Commit != "" {
+			key := authorCommit{line.Author, line.Commit}
+			if !commits[key] {
+				commits[key] = true
+				result.Commits[line.Author] = append(result.Commits[line.Author], line.Commit)
+			}
+		}
+	}
+
+	return result
+}
+
+// decodeGitName converts a name as emitted by git back to UTF-8. Names with
+// special bytes may be C-quoted with octal escapes ("J\303\274rgen"), and
+// names from commits recorded in a legacy encoding are not valid UTF-8, in
+// which case they are decoded as Latin-1.
+func decodeGitName(name string) string {
+	if len(name) >= 2 && strings.HasPrefix(name, `"`) && strings.HasSuffix(name, `"`) {
+		if unquoted, err := strconv.Unquote(name); err == nil {
+			name = unquoted
+		}
+	}
+
+	if utf8.ValidString(name) {
+		return name
+	}
+
+	runes := make([]rune, len(name))
+	for i := 0; i < len(name); i++ {
+		runes[i] = rune(name[i])
+	}
+	return string(runes)
+}
+
+// knownName groups commits recorded without an author name, as found in
+// some imported histories, under the --unknown-label
+func (ga *GitAnalyzer) knownName(name string) string {
+	if strings.TrimSpace(name) == "" {
+		return ga.config.UnknownLabel
+	}
+	return name
+}
+
+// timeBasis returns the commit date lines are dated by, author by default
+func (ga *GitAnalyzer) timeBasis() TimeBasis {
+	if ga.config.TimeBasis == "" {
+		return TimeAuthor
+	}
+	return ga.config.TimeBasis
+}
+
+// shouldExcludeAuthor checks if an author should be excluded
+func (ga *GitAnalyzer) shouldExcludeAuthor(author string) bool {
+	if ga.config.ExcludeBots && ga.isBot(author) {
+		return true
+	}
+	if ga.config.ExcludeUnknown && author == ga.config.UnknownLabel {
+		return true
+	}
+
+	// Check exclude list
+	for _, excluded := range ga.config.ExcludeAuthor {
+		if strings.EqualFold(author, excluded) {
+			return true
+		}
+	}
+
+	// Check include list (if specified, only include listed authors)
+	if len(ga.config.IncludeAuthor) > 0 {
+		included := false
+		for _, includedAuthor := range ga.config.IncludeAuthor {
+			if strings.EqualFold(author, includedAuthor) {
+				included = true
+				break
+			}
+		}
+		return !included
+	}
+
+	return false
+}
+
+// ProgressEvent is a machine-readable progress update emitted by --progress-json
+type ProgressEvent struct {
+	Processed int     `json:"processed"`
+	Total     int     `json:"total"`
+	Elapsed   float64 `json:"elapsed"`
+}
+
+// jsonProgress writes throttled ProgressEvents as JSON lines
+type jsonProgress struct {
+	mu        sync.Mutex
+	encoder   *json.Encoder
+	total     int
+	processed int
+	start     time.Time
+	lastEmit  time.Time
+	interval  time.Duration
+}
+
+// newJSONProgress creates a progress reporter emitting to stderr
+func newJSONProgress(total int) *jsonProgress {
+	return &jsonProgress{
+		encoder:  json.NewEncoder(os.Stderr),
+		total:    total,
+		start:    time.Now(),
+		interval: 250 * time.Millisecond,
+	}
+}
+
+// Add records completed files, emitting an event at most once per interval
+func (p *jsonProgress) Add(n int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.processed += n
+	if time.Since(p.lastEmit) >= p.interval {
+		p.emit()
+	}
+}
+
+// Finish emits the final event
+func (p *jsonProgress) Finish() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.emit()
+}
+
+func (p *jsonProgress) emit() {
+	p.lastEmit = time.Now()
+	p.encoder.Encode(ProgressEvent{
+		Processed: p.processed,
+		Total:     p.total,
+		Elapsed:   time.Since(p.start).Seconds(),
+	})
+}
+
+// processFiles processes files concurrently and returns analysis results
+func (ga *GitAnalyzer) processFiles(ctx context.Context, files []string) (*AnalysisResult, error) {
+	startTime := time.Now()
+
+	if ga.weighting(WeightFileImportance) && ga.importance == nil {
+		if err := ga.loadImportance(ctx); err != nil {
+			ga.logWarn("Failed to weigh files by importance: %v", err)
+		}
+	}
+
+	if ga.config.ResolveHandles && ga.handles == nil {
+		handles, err := ga.loadHandles()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read GitHub handles: %w", err)
+		}
+		ga.handles = handles
+	}
+
+	concurrency := ga.config.Concurrency
+	if concurrency <= 0 {
+		concurrency = runtime.NumCPU() * 2
+	}
+
+	var bar *progressbar.ProgressBar
+	var progress *jsonProgress
+	if ga.config.ProgressJSON {
+		progress = newJSONProgress(len(files))
+	} else if !ga.config.NoProgress && !ga.config.Quiet && isTerminal(os.Stderr) {
+		// The animated bar goes to stderr, and only when it's a terminal,
+		// so redirected output and logs stay free of control sequences
+		bar = progressbar.NewOptions(len(files),
+			progressbar.OptionSetWriter(os.Stderr),
+			progressbar.OptionSetDescription("Processing files"),
+			progressbar.OptionSetTheme(progressbar.Theme{
+				Saucer:        "█",
+				SaucerPadding: "░",
+				BarStart:      "[",
+				BarEnd:        "]",
+			}),
+			progressbar.OptionShowCount(),
+			progressbar.OptionShowIts(),
+			progressbar.OptionSetWidth(50),
+		)
+	}
+
+	// Reuse results from an interrupted run and checkpoint new ones
+	var resumed map[string]BlameResult
+	var resumedFrom string
+	if ga.config.Resume {
+		var err error
+		if resumed, resumedFrom, err = ga.loadCheckpoint(); err != nil {
+			ga.logWarn("Failed to load checkpoint: %v", err)
+		}
+	}
+
+	var cp *checkpoint
+	if !ga.config.NoCheckpoint {
+		var err error
+		if cp, err = ga.createCheckpoint(resumedFrom); err != nil {
+			ga.logWarn("Checkpointing disabled: %v", err)
+		}
+	}
+
+	resultsChan := make(chan BlameResult, len(files))
+	runCtx := ctx
+	g, ctx := errgroup.WithContext(ctx)
+	fileChan := make(chan string, len(files))
+
+	// With --ordered, each file's result waits in its own slot until the
+	// results of the files before it have been passed on
+	var slots []chan BlameResult
+	var slotOf map[string]int
+	if ga.config.Ordered {
+		slots = make([]chan BlameResult, len(files))
+		slotOf = make(map[string]int, len(files))
+		for i, file := range files {
+			slots[i] = make(chan BlameResult, 1)
+			slotOf[file] = i
+		}
+	}
+	emit := func(result BlameResult) {
+		if slots != nil {
+			slots[slotOf[result.FilePath]] <- result
+			return
+		}
+		resultsChan <- result
+	}
+
+	pending := make([]string, 0, len(files))
+	for _, file := range files {
+		if result, ok := resumed[file]; ok {
+			emit(result)
+			continue
+		}
+		pending = append(pending, file)
+	}
+
+	if done := len(files) - len(pending); done > 0 {
+		ga.logInfo("Resuming: %s of %s files already processed", ga.formatNumber(done), ga.formatNumber(len(files)))
+		if bar != nil {
+			bar.Add(done)
+		}
+		if progress != nil {
+			progress.Add(done)
+		}
+	}
+
+	var gate *lockGate
+	if ga.config.RetryOnLock {
+		gate = &lockGate{}
+	}
+
+	// Start workers
+	for i := 0; i < concurrency; i++ {
+		g.Go(func() error {
+			for filePath := range fileChan {
+				select {
+				case <-ctx.Done():
 					return ctx.Err()
 				default:
-					result := ga.runGitBlame(ctx, filePath)
-					resultsChan <- result
+					emit(ga.blameFile(ctx, filePath, gate))
 					if bar != nil {
 						bar.Add(1)
 					}
+					if progress != nil {
+						progress.Add(1)
+					}
 				}
 			}
 			return nil
@@ -437,7 +1601,7 @@ func (ga *GitAnalyzer) processFiles(ctx context.Context, files []string) (*Analy
 	// Send files to workers
 	go func() {
 		defer close(fileChan)
-		for _, file := range files {
+		for _, file := range pending {
 			select {
 			case fileChan <- file:
 			case <-ctx.Done():
@@ -446,85 +1610,385 @@ func (ga *GitAnalyzer) processFiles(ctx context.Context, files []string) (*Analy
 		}
 	}()
 
+	workersDone := make(chan struct{})
+	forwarded := make(chan struct{})
+	if slots != nil {
+		go func() {
+			defer close(forwarded)
+			for _, slot := range slots {
+				select {
+				case result := <-slot:
+					resultsChan <- result
+				case <-workersDone:
+					// Slots still empty now were never reached
+					select {
+					case result := <-slot:
+						resultsChan <- result
+					default:
+						return
+					}
+				}
+			}
+		}()
+	} else {
+		close(forwarded)
+	}
+
 	// Collect results
 	go func() {
 		g.Wait()
+		close(workersDone)
+		<-forwarded
 		close(resultsChan)
 	}()
 
-	// Process results
+	// Process results. Aggregation stays on this goroutine: the workers only
+	// blame files and send their results, and every count and map below,
+	// filesProcessed included, is updated here alone, so none of them needs
+	// a lock or an atomic. Keep it that way if result processing is ever
+	// spread across goroutines, or give the counters their own
+	// synchronization. On cancellation resultsChan is still drained to the
+	// end, so filesProcessed counts exactly the results received.
+	// TestAnalyzePipeline checks this under go test -race.
 	authorCounts := make(map[string]int)
+	authorWeighted := make(map[string]float64)
 	authorFiles := make(map[string]map[string]bool)
+	authorEmails := make(map[string]map[string]bool)
+	authorSpans := make(map[string]authorSpan)
+	authorCommits := make(map[string]map[string]bool)
+	// With a username, only the user's lines are tallied per author; the
+	// other authors just add to the totals behind the percentages
+	userOnly := ga.config.Username != ""
+	var tally *highlightTally
+	if ga.config.RichSummary && !userOnly {
+		tally = newHighlightTally()
+	}
+	var dirs *directoryTally
+	if ga.config.DirDepth > 0 {
+		dirs = newDirectoryTally(ga.config.DirDepth)
+	}
+	var copies *copyTally
+	if ga.config.CreditMoves == MovesProportional {
+		copies = newCopyTally()
+	}
+	var orphans []OrphanedFile
+	var authorFileLines map[string]map[string]int // lines by author and relative path, with --detailed-json
+	if ga.config.DetailedJSON {
+		authorFileLines = make(map[string]map[string]int)
+	}
 	userContributions := make(map[string]int)
+	userFileLines := make(map[string]int)
 	totalLines := 0
+	untrimmedLines := 0
+	weightedLines := 0.0
+	untrimmedWeighted := 0.0
 	filesProcessed := 0
 
 	for result := range resultsChan {
 		if result.Error != nil {
-			if ga.config.Verbose {
-				ga.logWarn("Error processing %s: %v", result.FilePath, result.Error)
-			}
+			ga.logDebug("Error processing %s: %v", result.FilePath, result.Error)
 			continue
 		}
 
+		if cp != nil {
+			if err := cp.record(result); err != nil {
+				ga.logWarn("Checkpointing disabled: %v", err)
+				cp.close()
+				cp = nil
+			}
+		}
+
 		filesProcessed++
+		untrimmedLines += len(result.Authors) + result.Excluded
+
+		if tally != nil || dirs != nil {
+			relPath, _ := filepath.Rel(ga.config.Directory, result.FilePath)
+			tallied := make([]string, 0, len(result.Authors))
+			for _, blamed := range result.Authors {
+				if blamed != "" {
+					tallied = append(tallied, ga.tallyName(blamed))
+				}
+			}
+			if tally != nil {
+				tally.addFile(result.FilePath, relPath, tallied)
+			}
+			if dirs != nil {
+				dirs.addFile(relPath, tallied)
+			}
+		}
 
-		for _, author := range result.Authors {
-			if author != "" {
-				authorCounts[author]++
+		if ga.config.OrphanThreshold > 0 {
+			if orphan := ga.orphanedFile(result); orphan != nil {
+				orphans = append(orphans, *orphan)
+			}
+		}
+
+		weight := ga.fileWeight(result.FilePath)
+		// Excluded lines have no times, so they aren't decayed
+		untrimmedWeighted += weight * float64(result.Excluded)
+
+		userPath := "" // relative path of the file, once the user owns a line
+		detailPath := ""
+		if authorFileLines != nil {
+			detailPath, _ = filepath.Rel(ga.config.Directory, result.FilePath)
+			detailPath = filepath.ToSlash(detailPath)
+		}
+
+		for i, blamed := range result.Authors {
+			lineWeight := weight
+			if i < len(result.Times) {
+				lineWeight *= ga.decayWeight(result.Times[i], startTime)
+			}
+			untrimmedWeighted += lineWeight
+
+			if blamed != "" {
 				totalLines++
+				weightedLines += lineWeight
+
+				tallied := !userOnly || ga.isUser(blamed)
+				author := ""
+				if tallied {
+					author = ga.tallyName(blamed)
+				}
+				if copies != nil && i < len(result.Origins) {
+					copies.add(author, result.Origins[i])
+				}
+				if !tallied {
+					continue
+				}
+
+				authorCounts[author]++
+				authorWeighted[author] += lineWeight
 
 				// Track files per author
 				if authorFiles[author] == nil {
 					authorFiles[author] = make(map[string]bool)
 				}
 				authorFiles[author][result.FilePath] = true
+				if authorFileLines != nil {
+					if authorFileLines[author] == nil {
+						authorFileLines[author] = make(map[string]int)
+					}
+					authorFileLines[author][detailPath]++
+				}
+
+				if span, ok := result.Spans[blamed]; ok {
+					authorSpans[author] = authorSpans[author].extend(span.First).extend(span.Last)
+				}
+
+				if email := result.Emails[blamed]; email != "" {
+					if authorEmails[author] == nil {
+						authorEmails[author] = make(map[string]bool)
+					}
+					authorEmails[author][email] = true
+				}
 
 				// If filtering for specific user, track per-file contributions
-				if ga.config.Username != "" && author == ga.config.Username {
-					relPath, _ := filepath.Rel(ga.config.Directory, result.FilePath)
-					userContributions[relPath]++
+				if userOnly {
+					if userPath == "" {
+						userPath, _ = filepath.Rel(ga.config.Directory, result.FilePath)
+						userFileLines[userPath] = len(result.Authors) + result.Excluded
+					}
+					userContributions[userPath]++
 				}
 			}
 		}
+
+		for blamed, commits := range result.Commits {
+			if userOnly && !ga.isUser(blamed) {
+				continue
+			}
+			author := ga.tallyName(blamed)
+			if authorCommits[author] == nil {
+				authorCommits[author] = make(map[string]bool)
+			}
+			for _, commit := range commits {
+				authorCommits[author][commit] = true
+			}
+		}
 	}
 
 	if bar != nil {
 		bar.Finish()
-		fmt.Println()
+		fmt.Fprintln(os.Stderr)
+	}
+	if progress != nil {
+		progress.Finish()
 	}
 
-	if err := g.Wait(); err != nil {
-		return nil, err
+	// An interrupt can also land after every file was handed out, failing
+	// the blames in flight without stopping a worker
+	err := g.Wait()
+	if err == nil {
+		err = runCtx.Err()
+	}
+	partial := false
+	if err != nil {
+		if cp != nil {
+			if cerr := cp.close(); cerr == nil && errors.Is(err, context.Canceled) {
+				ga.logInfo("Progress saved; rerun with --resume to continue")
+			}
+			cp = nil
+		}
+		if !ga.config.PartialResults || !errors.Is(err, context.Canceled) {
+			return nil, err
+		}
+		// Only files whose blame completed were tallied, so the partial
+		// results are consistent, just incomplete
+		partial = true
+		ga.logWarn("Interrupted after %s of %s files; showing partial results",
+			ga.formatNumber(filesProcessed), ga.formatNumber(len(files)))
+	}
+
+	if cp != nil {
+		cp.remove()
+	}
+
+	// Copied lines count once in total, split between their copies
+	var authorCredits map[string]float64
+	creditTotal := 0.0
+	if copies != nil {
+		authorCredits, creditTotal = copies.credits()
+		for author, credit := range authorCredits {
+			authorCounts[author] = roundCredit(credit)
+		}
+		untrimmedLines -= totalLines - roundCredit(creditTotal)
+		totalLines = roundCredit(creditTotal)
+	}
+
+	// Percentages are relative to the included authors' lines unless the
+	// excluded authors' lines should stay in the denominator
+	denominator := float64(totalLines)
+	if authorCredits != nil {
+		denominator = creditTotal
+	}
+	if ga.config.NoTrimTotal {
+		denominator = float64(untrimmedLines)
+	}
+	if ga.config.Weighted {
+		denominator = weightedLines
+		if ga.config.NoTrimTotal {
+			denominator = untrimmedWeighted
+		}
 	}
 
 	// Convert to sorted slices
 	authors := make([]AuthorStats, 0, len(authorCounts))
 	for name, count := range authorCounts {
-		if count >= ga.config.MinLines {
-			fileCount := len(authorFiles[name])
-			percentage := float64(count) / float64(totalLines) * 100
-			authors = append(authors, AuthorStats{
-				Name:       name,
-				LineCount:  count,
-				FileCount:  fileCount,
-				Percentage: percentage,
-			})
+		span := authorSpans[name]
+		active := ga.config.ActiveSince.IsZero() || span.Last >= ga.config.ActiveSince.Unix()
+		fileCount := len(authorFiles[name])
+		if count >= ga.config.MinLines && fileCount >= ga.config.MinFiles && active {
+			share := float64(count)
+			if authorCredits != nil {
+				share = authorCredits[name]
+			}
+			if ga.config.Weighted {
+				share = authorWeighted[name]
+			}
+			stats := AuthorStats{
+				Name:        name,
+				LineCount:   count,
+				FileCount:   fileCount,
+				CommitCount: len(authorCommits[name]),
+				Percentage:  share / denominator * 100,
+			}
+			if fileCount > 0 {
+				stats.LinesPerFile = float64(count) / float64(fileCount)
+			}
+			if authorFileLines != nil {
+				stats.Files = authorFileList(authorFileLines[name])
+			}
+			if len(ga.config.WeightStrategies) > 0 {
+				stats.WeightedLines = authorWeighted[name]
+			}
+			if ga.config.ResolveHandles {
+				stats.GitHubHandle = ga.resolveHandle(name, authorEmails[name])
+			}
+			if ga.authorFormatUses("email") {
+				stats.Email = primaryEmail(authorEmails[name])
+			}
+			if span.Last > 0 {
+				stats.FirstCommit = formatCommitDate(span.First)
+				stats.LastCommit = formatCommitDate(span.Last)
+			}
+			authors = append(authors, stats)
 		}
 	}
 
+	// Scores and ranks compare authors, so they're left out when only the
+	// user was tallied
+	if !userOnly {
+		if ga.config.Score {
+			ga.assignScores(authors, authorSpans, time.Now())
+		}
+
+		assignPercentiles(authors)
+		ga.assignRanks(authors)
+	}
+
 	// Sort authors
 	ga.sortAuthors(authors)
+	ga.labelAuthors(authors)
+
+	var highlights *Highlights
+	if tally != nil {
+		highlights = tally.highlights(authors, authorSpans)
+	}
+
+	// Tiers and metrics cover every author, including those cut by --limit
+	var tiers []TierStats
+	if ga.config.Tiers && !userOnly {
+		tiers = ga.assignTiers(authors)
+	}
+	var metrics *Metrics
+	var filterTotals *FilterTotals
+	if !userOnly {
+		metrics = ga.ownershipMetrics(authors)
+		filterTotals = ga.filterTotals(totalLines, untrimmedLines)
+	}
+	var directories []DirectoryStats
+	if dirs != nil {
+		directories = dirs.directories(totalLines)
+	}
 
-	// Limit results if specified
+	// Limit results if specified, summarizing the truncated authors
+	authorsFound := len(authors)
+	var others *OthersStats
 	if ga.config.MaxResults > 0 && len(authors) > ga.config.MaxResults {
+		if !ga.config.NoOthers {
+			others = summarizeOthers(authors[ga.config.MaxResults:], authorFiles)
+		}
 		authors = authors[:ga.config.MaxResults]
 	}
 
+	// Roll individual authors up into teams
+	var teams []TeamStats
+	if ga.config.ByTeam {
+		teams = ga.aggregateTeams(authorCounts, authorWeighted, authorFiles, authorEmails, denominator)
+	}
+
+	// Connect the listed authors who own lines in the same files
+	var coOwners []CoOwnership
+	if ga.writes(FormatDot) {
+		coOwners = coOwnership(authors, filesByAuthor(authorFiles))
+	}
+
 	// Convert user contributions to sorted slice
+	userTotal := 0
+	for _, count := range userContributions {
+		userTotal += count
+	}
+
 	contributions := make([]FileContribution, 0, len(userContributions))
 	for path, count := range userContributions {
-		contributions = append(contributions, FileContribution{Path: path, LineCount: count})
+		contributions = append(contributions, FileContribution{
+			Path:      path,
+			LineCount: count,
+			FileLines: userFileLines[path],
+			Share:     float64(count) / float64(userTotal) * 100,
+			Ownership: float64(count) / float64(userFileLines[path]) * 100,
+		})
 	}
 
 	sort.Slice(contributions, func(i, j int) bool {
@@ -536,6 +2000,8 @@ func (ga *GitAnalyzer) processFiles(ctx context.Context, files []string) (*Analy
 		contributions = contributions[:ga.config.MaxResults]
 	}
 
+	sortOrphans(orphans)
+
 	return &AnalysisResult{
 		Authors:           authors,
 		UserContributions: contributions,
@@ -545,19 +2011,134 @@ func (ga *GitAnalyzer) processFiles(ctx context.Context, files []string) (*Analy
 		ProcessingTime:    time.Since(startTime),
 		Repository:        ga.config.Directory,
 		GeneratedAt:       time.Now(),
+		SkippedLarge:      ga.skippedLarge,
+		SkippedLFS:        ga.skippedLFS,
+		SkippedBinary:     ga.skippedBinary,
+		SkippedUntracked:  ga.skippedUntracked,
+		SkippedStale:      ga.skippedStale,
+		UnfilteredLines:   ga.untrimmedTotal(untrimmedLines),
+		FilterTotals:      filterTotals,
+		Others:            others,
+		Teams:             teams,
+		Tiers:             tiers,
+		CoOwnership:       coOwners,
+		Highlights:        highlights,
+		OrphanedFiles:     orphans,
+		Partial:           partial,
+		Metrics:           metrics,
+		Directories:       directories,
+		AuthorsFound:      authorsFound,
 	}, nil
 }
 
+// authorFileList lists an author's files by lines, most first, then by path
+func authorFileList(lines map[string]int) []AuthorFile {
+	files := make([]AuthorFile, 0, len(lines))
+	for path, count := range lines {
+		files = append(files, AuthorFile{Path: path, LineCount: count})
+	}
+	sort.Slice(files, func(i, j int) bool {
+		if files[i].LineCount != files[j].LineCount {
+			return files[i].LineCount > files[j].LineCount
+		}
+		return files[i].Path < files[j].Path
+	})
+	return files
+}
+
+// summarizeOthers aggregates truncated authors into a single row. Files are
+// counted once even when several of the authors contributed to them.
+func summarizeOthers(truncated []AuthorStats, authorFiles map[string]map[string]bool) *OthersStats {
+	others := &OthersStats{Authors: len(truncated)}
+	files := make(map[string]bool)
+
+	for _, author := range truncated {
+		others.LineCount += author.LineCount
+		others.Percentage += author.Percentage
+		for file := range authorFiles[author.Name] {
+			files[file] = true
+		}
+	}
+
+	others.FileCount = len(files)
+	return others
+}
+
+// assignPercentiles sets each author's "top N%" rank by line count. Authors
+// with equal line counts share the best rank of their group.
+func assignPercentiles(authors []AuthorStats) {
+	counts := make([]int, len(authors))
+	for i, author := range authors {
+		counts[i] = author.LineCount
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(counts)))
+
+	for i := range authors {
+		// Number of authors with strictly more lines
+		ahead := sort.Search(len(counts), func(j int) bool {
+			return counts[j] <= authors[i].LineCount
+		})
+		authors[i].Percentile = float64(ahead+1) / float64(len(authors)) * 100
+	}
+}
+
+// untrimmedTotal returns the line count including excluded authors when it
+// is used as the percentage denominator
+func (ga *GitAnalyzer) untrimmedTotal(lines int) int {
+	if ga.config.NoTrimTotal {
+		return lines
+	}
+	return 0
+}
+
+// assignRanks numbers authors by lines (weighted lines with --weighted), so
+// the rank survives re-sorting by name or files
+func (ga *GitAnalyzer) assignRanks(authors []AuthorStats) {
+	sort.Slice(authors, func(i, j int) bool {
+		return ga.rankedBefore(authors[i], authors[j])
+	})
+	for i := range authors {
+		authors[i].Rank = i + 1
+	}
+}
+
+// rankedBefore orders authors by lines, or by score with --score, breaking
+// ties by name
+func (ga *GitAnalyzer) rankedBefore(a, b AuthorStats) bool {
+	if ga.config.Score && a.Score != b.Score {
+		return a.Score > b.Score
+	}
+	return ga.linesBefore(a, b)
+}
+
+// linesBefore orders authors by lines (weighted lines with --weighted),
+// breaking ties by name
+func (ga *GitAnalyzer) linesBefore(a, b AuthorStats) bool {
+	if ga.config.Weighted && a.WeightedLines != b.WeightedLines {
+		return a.WeightedLines > b.WeightedLines
+	}
+	if a.LineCount != b.LineCount {
+		return a.LineCount > b.LineCount
+	}
+	return a.Name < b.Name
+}
+
 // sortAuthors sorts authors based on the configured sort option
 func (ga *GitAnalyzer) sortAuthors(authors []AuthorStats) {
 	switch ga.config.SortBy {
 	case SortByLines:
 		sort.Slice(authors, func(i, j int) bool {
-			return authors[i].LineCount > authors[j].LineCount
+			return ga.linesBefore(authors[i], authors[j])
+		})
+	case SortByScore:
+		sort.Slice(authors, func(i, j int) bool {
+			return ga.rankedBefore(authors[i], authors[j])
 		})
 	case SortByName:
+		// With --anonymize, order by the identifiers shown rather than by
+		// the real names, which the order would otherwise leak
 		sort.Slice(authors, func(i, j int) bool {
-			return authors[i].Name < authors[j].Name
+			return ga.anonymize(authors[i].Name) < ga.anonymize(authors[j].Name)
 		})
 	case SortByFiles:
 		sort.Slice(authors, func(i, j int) bool {
@@ -566,48 +2147,158 @@ func (ga *GitAnalyzer) sortAuthors(authors []AuthorStats) {
 	}
 }
 
-// displayResults displays the analysis results based on format
-func (ga *GitAnalyzer) displayResults(result *AnalysisResult) error {
-	switch ga.config.OutputFormat {
-	case FormatJSON:
-		return ga.outputJSON(result)
-	case FormatCSV:
-		return ga.outputCSV(result)
-	case FormatPlain:
-		return ga.outputPlain(result)
-	default:
-		return ga.outputTable(result)
-	}
-}
-
 // outputJSON outputs results in JSON format
-func (ga *GitAnalyzer) outputJSON(result *AnalysisResult) error {
-	encoder := json.NewEncoder(os.Stdout)
+func (ga *GitAnalyzer) outputJSON(w io.Writer, result *AnalysisResult) error {
+	encoder := json.NewEncoder(w)
 	encoder.SetIndent("", "  ")
+
+	if len(ga.config.Fields) > 0 {
+		authors, err := projectAuthors(result.Authors, ga.config.Fields)
+		if err != nil {
+			return err
+		}
+		// The outer Authors field shadows the embedded one
+		return encoder.Encode(struct {
+			Authors []projectedAuthor `json:"authors"`
+			*AnalysisResult
+		}{authors, result})
+	}
+
 	return encoder.Encode(result)
 }
 
+// authorFields returns the JSON field names of AuthorStats in declaration order
+func authorFields() []string {
+	t := reflect.TypeOf(AuthorStats{})
+	fields := make([]string, 0, t.NumField())
+
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			fields = append(fields, name)
+		}
+	}
+
+	return fields
+}
+
+// validateFields checks requested --fields against the known author fields
+func validateFields(fields []string) error {
+	valid := authorFields()
+	for _, field := range fields {
+		if !slices.Contains(valid, field) {
+			return fmt.Errorf("unknown field %q (valid fields: %s)", field, strings.Join(valid, ", "))
+		}
+	}
+	return nil
+}
+
+// projectedAuthor marshals a subset of an author's JSON fields in the requested order
+type projectedAuthor struct {
+	fields []string
+	values map[string]json.RawMessage
+}
+
+func (p projectedAuthor) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+
+	first := true
+	for _, field := range p.fields {
+		value, ok := p.values[field]
+		if !ok {
+			continue // omitted by omitempty
+		}
+		if !first {
+			buf.WriteByte(',')
+		}
+		first = false
+
+		key, _ := json.Marshal(field)
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// projectAuthors reduces each author to the requested JSON fields
+func projectAuthors(authors []AuthorStats, fields []string) ([]projectedAuthor, error) {
+	projected := make([]projectedAuthor, 0, len(authors))
+
+	for _, author := range authors {
+		data, err := json.Marshal(author)
+		if err != nil {
+			return nil, err
+		}
+
+		var values map[string]json.RawMessage
+		if err := json.Unmarshal(data, &values); err != nil {
+			return nil, err
+		}
+
+		projected = append(projected, projectedAuthor{fields: fields, values: values})
+	}
+
+	return projected, nil
+}
+
 // outputCSV outputs results in CSV format
-func (ga *GitAnalyzer) outputCSV(result *AnalysisResult) error {
-	writer := csv.NewWriter(os.Stdout)
+func (ga *GitAnalyzer) outputCSV(w io.Writer, result *AnalysisResult) error {
+	if ga.config.CSVBOM {
+		// Lets Excel detect UTF-8 so non-ASCII author names aren't garbled
+		io.WriteString(w, "\ufeff")
+	}
+
+	writer := csv.NewWriter(w)
+	writer.Comma = ga.config.CSVDelimiter
 	defer writer.Flush()
 
-	if ga.config.Username != "" {
+	if ga.config.DiffBase != "" {
+		// Prior owners of changed lines
+		writer.Write([]string{"File", "Author", "Lines"})
+		for _, file := range result.DiffFiles {
+			for _, owner := range file.Owners {
+				writer.Write([]string{file.Path, owner.Name, strconv.Itoa(owner.LineCount)})
+			}
+		}
+	} else if ga.config.Username != "" {
 		// User-specific CSV
-		writer.Write([]string{"File", "Lines"})
+		writer.Write([]string{"File", "Lines", "File Lines", "Share", "Ownership"})
 		for _, contrib := range result.UserContributions {
-			writer.Write([]string{contrib.Path, strconv.Itoa(contrib.LineCount)})
+			writer.Write([]string{
+				contrib.Path,
+				strconv.Itoa(contrib.LineCount),
+				strconv.Itoa(contrib.FileLines),
+				ga.csvPercent(contrib.Share),
+				ga.csvPercent(contrib.Ownership),
+			})
 		}
 	} else {
 		// Authors CSV
-		writer.Write([]string{"Author", "Lines", "Files", "Percentage"})
+		columns, err := parseCSVColumns(ga.config.CSVColumns)
+		if err != nil {
+			return err
+		}
+		headers := make([]string, len(columns))
+		for i, column := range columns {
+			headers[i] = column.header
+		}
+		writer.Write(headers)
 		for _, author := range result.Authors {
-			writer.Write([]string{
-				author.Name,
-				strconv.Itoa(author.LineCount),
-				strconv.Itoa(author.FileCount),
-				fmt.Sprintf("%.2f", author.Percentage),
-			})
+			row := make([]string, len(columns))
+			for i, column := range columns {
+				if column.percent != nil {
+					row[i] = ga.csvPercent(column.percent(author))
+				} else if column.date != nil {
+					row[i] = ga.displayDate(column.date(author))
+				} else {
+					row[i] = column.value(author)
+				}
+			}
+			writer.Write(row)
 		}
 	}
 
@@ -615,44 +2306,139 @@ func (ga *GitAnalyzer) outputCSV(result *AnalysisResult) error {
 }
 
 // outputPlain outputs results in plain text format
-func (ga *GitAnalyzer) outputPlain(result *AnalysisResult) error {
-	if ga.config.Username != "" {
-		fmt.Printf("User: %s\n", ga.config.Username)
-		fmt.Printf("Total Lines: %s\n", formatNumber(result.getTotalUserLines()))
-		fmt.Printf("Files: %d\n\n", len(result.UserContributions))
+func (ga *GitAnalyzer) outputPlain(w io.Writer, result *AnalysisResult) error {
+	var rows [][]string
+
+	if ga.config.DiffBase != "" {
+		fmt.Fprintf(w, "Base: %s\n", result.DiffBase)
+		fmt.Fprintf(w, "Changed Lines: %s\n", ga.formatNumber(result.TotalLines))
+		fmt.Fprintf(w, "Files: %d\n\n", len(result.DiffFiles))
+
+		for _, file := range result.DiffFiles {
+			rows = append(rows, []string{ga.formatNumber(file.ChangedLines), file.Path, ga.formatOwners(file.Owners)})
+		}
+	} else if ga.config.Username != "" {
+		fmt.Fprintf(w, "User: %s\n", ga.anonymize(ga.config.Username))
+		fmt.Fprintf(w, "Total Lines: %s\n", ga.formatNumber(result.getTotalUserLines()))
+		fmt.Fprintf(w, "Files: %d\n\n", len(result.UserContributions))
 
 		for _, contrib := range result.UserContributions {
-			fmt.Printf("%s\t%s\n", formatNumber(contrib.LineCount), contrib.Path)
+			rows = append(rows, []string{
+				ga.formatNumber(contrib.LineCount),
+				ga.formatPercent(contrib.Share, 2),
+				ga.formatPercent(contrib.Ownership, 2),
+				contrib.Path,
+			})
 		}
 	} else {
-		fmt.Printf("Total Lines: %s\n", formatNumber(result.TotalLines))
-		fmt.Printf("Authors: %d\n", len(result.Authors))
-		fmt.Printf("Files: %d\n\n", result.FilesProcessed)
+		fmt.Fprintf(w, "Total Lines: %s\n", ga.formatNumber(result.TotalLines))
+		if result.Sampled {
+			fmt.Fprintf(w, "Estimated Total Lines: %s (sampled %d of %d files)\n",
+				ga.formatNumber(result.EstimatedTotalLines), result.SampleSize, result.TotalFiles)
+		}
+		if ft := result.FilterTotals; ft != nil {
+			fmt.Fprintf(w, "Filtered: analyzed %s lines of %s total (%s after filters)\n",
+				ga.formatNumber(ft.AnalyzedLines), ga.formatNumber(ft.TotalLines), ga.formatPercent(ft.Percentage, 1))
+		}
+		fmt.Fprintf(w, "Authors: %d\n", len(result.Authors))
+		if result.Partial {
+			fmt.Fprintf(w, "Partial: interrupted after %d of %d files\n", result.FilesProcessed, result.TotalFiles)
+		}
+		fmt.Fprintf(w, "Files: %d\n\n", result.FilesProcessed)
 
 		for _, author := range result.Authors {
-			fmt.Printf("%s\t%s\t%s\t%.2f%%\n",
-				formatNumber(author.LineCount),
-				formatNumber(author.FileCount),
+			rows = append(rows, []string{
+				ga.formatNumber(author.LineCount),
+				ga.formatNumber(author.FileCount),
 				author.Name,
-				author.Percentage)
+				ga.formatPercent(author.Percentage, 2),
+			})
+		}
+
+		if others := result.Others; others != nil {
+			rows = append(rows, []string{
+				ga.formatNumber(others.LineCount),
+				ga.formatNumber(others.FileCount),
+				others.Label(),
+				ga.formatPercent(others.Percentage, 2),
+			})
 		}
 	}
 
+	ga.writePlainRows(w, rows)
 	return nil
 }
 
+// writePlainRows writes plain output rows joined by the configured delimiter,
+// or with columns padded to a common width in aligned mode
+func (ga *GitAnalyzer) writePlainRows(w io.Writer, rows [][]string) {
+	delimiter := ga.config.PlainDelimiter
+	switch delimiter {
+	case "", PlainTab:
+		delimiter = "\t"
+	case PlainSpace:
+		delimiter = " "
+	case PlainAligned:
+		widths := make(map[int]int)
+		for _, row := range rows {
+			for i, cell := range row {
+				widths[i] = max(widths[i], lipgloss.Width(cell))
+			}
+		}
+
+		for _, row := range rows {
+			var line strings.Builder
+			for i, cell := range row {
+				if i == len(row)-1 {
+					line.WriteString(cell)
+					break
+				}
+				line.WriteString(cell)
+				line.WriteString(strings.Repeat(" ", widths[i]-lipgloss.Width(cell)+2))
+			}
+			fmt.Fprintln(w, line.String())
+		}
+		return
+	}
+
+	for _, row := range rows {
+		fmt.Fprintln(w, strings.Join(row, delimiter))
+	}
+}
+
 // outputTable outputs results in table format
-func (ga *GitAnalyzer) outputTable(result *AnalysisResult) error {
-	if ga.config.Username != "" {
-		return ga.displayUserResults(result)
+func (ga *GitAnalyzer) outputTable(w io.Writer, result *AnalysisResult) error {
+	ga.displayRepoStats(w, result)
+
+	var err error
+	switch {
+	case ga.config.DiffBase != "":
+		err = ga.displayDiffResults(w, result)
+	case ga.config.Username != "":
+		err = ga.displayUserResults(w, result)
+	case ga.config.ByTeam:
+		err = ga.displayTeamResults(w, result)
+	case ga.config.Tiers:
+		err = ga.displayTierResults(w, result)
+	case ga.config.DirDepth > 0:
+		err = ga.displayDirectoryResults(w, result)
+	default:
+		if err = ga.displayAuthorResults(w, result); err == nil {
+			ga.displayFileHistory(w, result)
+		}
+	}
+	if err != nil {
+		return err
 	}
-	return ga.displayAuthorResults(result)
+	ga.displayOrphans(w, result)
+	ga.displayAliases(w, result)
+	return nil
 }
 
 // displayAuthorResults displays results for all authors
-func (ga *GitAnalyzer) displayAuthorResults(result *AnalysisResult) error {
+func (ga *GitAnalyzer) displayAuthorResults(w io.Writer, result *AnalysisResult) error {
 	if !ga.config.Quiet {
-		fmt.Printf("\n%s\n", ga.styleHeader("Author Contributions"))
+		fmt.Fprintf(w, "\n%s\n", ga.styleHeader("Author Contributions"))
 	}
 
 	if len(result.Authors) == 0 {
@@ -662,51 +2448,119 @@ func (ga *GitAnalyzer) displayAuthorResults(result *AnalysisResult) error {
 		return nil
 	}
 
-	table := tablewriter.NewWriter(os.Stdout)
-	headers := []string{"Rank", "Lines", "Files", "Percentage", "Author"}
+	table := ga.newTable(w)
+	headers := []string{"Lines", "Files", "Percentage", "Author"}
+	showRank := ga.rankStyle() != RankNone
 
-	if !ga.config.IncludeEmoji {
-		headers[0] = "Rank"
+	if ga.config.Weighted {
+		headers = slices.Insert(headers, 1, "Weighted")
+	}
+	commitsCol := slices.Index(headers, "Files") + 1
+	if ga.config.ShowCommits {
+		headers = slices.Insert(headers, commitsCol, "Commits")
+	}
+	if ga.config.ShowAverage {
+		headers = slices.Insert(headers, commitsCol, "Avg Lines")
+	}
+	if ga.config.ShowPercentile {
+		headers = slices.Insert(headers, len(headers)-1, "Percentile")
+	}
+	if ga.config.Score {
+		headers = slices.Insert(headers, len(headers)-1, "Score")
+	}
+	if ga.config.ResolveHandles {
+		headers = append(headers, "Handle")
+	}
+	if showRank {
+		headers = append([]string{"Rank"}, headers...)
 	}
 
 	table.Header(headers)
 
-	for i, author := range result.Authors {
-		rank := fmt.Sprintf("%d", i+1)
+	for _, author := range result.Authors {
+		row := []string{
+			ga.formatNumber(author.LineCount),
+			ga.formatNumber(author.FileCount),
+			ga.formatPercent(author.Percentage, 1),
+			author.Name,
+		}
 
-		if ga.config.IncludeEmoji {
-			switch i {
-			case 0:
-				rank = "🥇"
-			case 1:
-				rank = "🥈"
-			case 2:
-				rank = "🥉"
-			}
+		if ga.config.Weighted {
+			row = slices.Insert(row, 1, ga.printer.Sprintf("%.1f", author.WeightedLines))
+		}
+		if ga.config.ShowCommits {
+			row = slices.Insert(row, commitsCol, ga.formatNumber(author.CommitCount))
+		}
+		if ga.config.ShowAverage {
+			row = slices.Insert(row, commitsCol, ga.printer.Sprintf("%.1f", author.LinesPerFile))
+		}
+		if ga.config.ShowPercentile {
+			row = slices.Insert(row, len(row)-1, "Top "+ga.formatPercent(author.Percentile, 1))
+		}
+		if ga.config.Score {
+			row = slices.Insert(row, len(row)-1, ga.printer.Sprintf("%.1f", author.Score))
+		}
+		if ga.config.ResolveHandles {
+			row = append(row, mention(author.GitHubHandle))
+		}
+		if showRank {
+			row = append([]string{ga.rankLabel(author.Rank - 1)}, row...)
+		}
+
+		table.Append(row)
+	}
+
+	if others := result.Others; others != nil {
+		row := []string{
+			ga.formatNumber(others.LineCount),
+			ga.formatNumber(others.FileCount),
+			ga.formatPercent(others.Percentage, 1),
+			others.Label(),
+		}
+
+		if ga.config.Weighted {
+			row = slices.Insert(row, 1, "")
+		}
+		if ga.config.ShowCommits {
+			row = slices.Insert(row, commitsCol, "")
+		}
+		if ga.config.ShowAverage {
+			row = slices.Insert(row, commitsCol, "")
+		}
+		if ga.config.ShowPercentile {
+			row = slices.Insert(row, len(row)-1, "")
+		}
+		if ga.config.Score {
+			row = slices.Insert(row, len(row)-1, "")
+		}
+		if ga.config.ResolveHandles {
+			row = append(row, "")
+		}
+		if showRank {
+			row = append([]string{""}, row...)
 		}
 
-		table.Append([]string{
-			rank,
-			formatNumber(author.LineCount),
-			formatNumber(author.FileCount),
-			fmt.Sprintf("%.1f%%", author.Percentage),
-			author.Name,
-		})
+		table.Append(row)
 	}
 
 	table.Render()
 
+	if ga.config.ImplicitLimit && result.AuthorsFound > len(result.Authors) {
+		ga.logInfo("Showing the top %s of %s authors; use --limit 0 for all",
+			ga.formatNumber(len(result.Authors)), ga.formatNumber(result.AuthorsFound))
+	}
+
 	if !ga.config.Quiet {
-		ga.displaySummary(result)
+		ga.displaySummary(w, result)
 	}
 
 	return nil
 }
 
 // displayUserResults displays results for a specific user
-func (ga *GitAnalyzer) displayUserResults(result *AnalysisResult) error {
+func (ga *GitAnalyzer) displayUserResults(w io.Writer, result *AnalysisResult) error {
 	if !ga.config.Quiet {
-		fmt.Printf("\n%s\n", ga.styleHeader(fmt.Sprintf("%s's Contributions", ga.config.Username)))
+		fmt.Fprintf(w, "\n%s\n", ga.styleHeader(fmt.Sprintf("%s's Contributions", ga.anonymize(ga.config.Username))))
 	}
 
 	if len(result.UserContributions) == 0 {
@@ -716,29 +2570,40 @@ func (ga *GitAnalyzer) displayUserResults(result *AnalysisResult) error {
 		return nil
 	}
 
-	table := tablewriter.NewWriter(os.Stdout)
-	table.Header([]string{"Lines", "File"})
+	table := ga.newTable(w)
+	headers := []string{"Lines", "Share", "Ownership", "File"}
+	if ga.config.FollowRenames {
+		headers = slices.Insert(headers, 1, "Ever Added")
+	}
+	table.Header(headers)
 
 	for _, contrib := range result.UserContributions {
-		table.Append([]string{
-			formatNumber(contrib.LineCount),
+		row := []string{
+			ga.formatNumber(contrib.LineCount),
+			ga.formatPercent(contrib.Share, 1),
+			fmt.Sprintf("%s of %s", ga.formatPercent(contrib.Ownership, 1), ga.formatNumber(contrib.FileLines)),
 			contrib.Path,
-		})
+		}
+		if ga.config.FollowRenames {
+			row = slices.Insert(row, 1, ga.formatNumber(contrib.LinesAdded))
+		}
+		table.Append(row)
 	}
 
 	table.Render()
 
 	if !ga.config.Quiet {
-		summaryTable := tablewriter.NewWriter(os.Stdout)
+		summaryTable := ga.newTable(w)
 		summaryTable.Header([]string{"Metric", "Value"})
 
 		userTotal := result.getTotalUserLines()
 
-		summaryTable.Append([]string{"Total lines", formatNumber(userTotal)})
-		summaryTable.Append([]string{"Files contributed", formatNumber(len(result.UserContributions))})
+		summaryTable.Append([]string{"Total lines", ga.formatNumber(userTotal)})
+		summaryTable.Append([]string{"Files contributed", ga.formatNumber(len(result.UserContributions))})
 		summaryTable.Append([]string{"Processing time", result.ProcessingTime.Round(time.Millisecond).String()})
+		ga.appendSkippedRows(summaryTable, result)
 
-		fmt.Printf("\n%s\n", ga.styleHeader("Summary"))
+		fmt.Fprintf(w, "\n%s\n", ga.styleHeader("Summary"))
 		summaryTable.Render()
 	}
 
@@ -746,19 +2611,62 @@ func (ga *GitAnalyzer) displayUserResults(result *AnalysisResult) error {
 }
 
 // displaySummary displays summary statistics
-func (ga *GitAnalyzer) displaySummary(result *AnalysisResult) {
-	summaryTable := tablewriter.NewWriter(os.Stdout)
+func (ga *GitAnalyzer) displaySummary(w io.Writer, result *AnalysisResult) {
+	summaryTable := ga.newTable(w)
 	summaryTable.Header([]string{"Metric", "Value"})
 
-	summaryTable.Append([]string{"Total lines analyzed", formatNumber(result.TotalLines)})
-	summaryTable.Append([]string{"Unique authors", formatNumber(len(result.Authors))})
-	summaryTable.Append([]string{"Files processed", formatNumber(result.FilesProcessed)})
+	summaryTable.Append([]string{"Total lines analyzed", ga.formatNumber(result.TotalLines)})
+	if result.Sampled {
+		summaryTable.Append([]string{"Estimated total lines", ga.formatNumber(result.EstimatedTotalLines)})
+		summaryTable.Append([]string{"Files sampled", fmt.Sprintf("%s of %s", ga.formatNumber(result.SampleSize), ga.formatNumber(result.TotalFiles))})
+	}
+	if result.UnfilteredLines > 0 {
+		summaryTable.Append([]string{"Lines incl. excluded authors", ga.formatNumber(result.UnfilteredLines)})
+	}
+	if ft := result.FilterTotals; ft != nil {
+		summaryTable.Append([]string{"Lines after filters", fmt.Sprintf("%s of %s (%s)",
+			ga.formatNumber(ft.AnalyzedLines), ga.formatNumber(ft.TotalLines), ga.formatPercent(ft.Percentage, 1))})
+	}
+	summaryTable.Append([]string{"Unique authors", ga.formatNumber(len(result.Authors))})
+	summaryTable.Append([]string{"Files processed", ga.formatNumber(result.FilesProcessed)})
+	if result.Partial {
+		summaryTable.Append([]string{"Partial results", fmt.Sprintf("interrupted after %s of %s files",
+			ga.formatNumber(result.FilesProcessed), ga.formatNumber(result.TotalFiles))})
+	}
+	if len(result.Branches) > 0 {
+		summaryTable.Append([]string{"Branches analyzed", ga.formatNumber(len(result.Branches))})
+	}
 	summaryTable.Append([]string{"Processing time", result.ProcessingTime.Round(time.Millisecond).String()})
+	ga.appendSkippedRows(summaryTable, result)
+	if result.Highlights != nil {
+		for _, row := range ga.highlightRows(result.Highlights) {
+			summaryTable.Append(row)
+		}
+	}
 
-	fmt.Printf("\n%s\n", ga.styleHeader("Summary"))
+	fmt.Fprintf(w, "\n%s\n", ga.styleHeader("Summary"))
 	summaryTable.Render()
 }
 
+// appendSkippedRows adds counts of files skipped by pre-filters to a summary table
+func (ga *GitAnalyzer) appendSkippedRows(table *tablewriter.Table, result *AnalysisResult) {
+	if result.SkippedLarge > 0 {
+		table.Append([]string{"Large files skipped", ga.formatNumber(result.SkippedLarge)})
+	}
+	if result.SkippedLFS > 0 {
+		table.Append([]string{"LFS pointers skipped", ga.formatNumber(result.SkippedLFS)})
+	}
+	if result.SkippedBinary > 0 {
+		table.Append([]string{"Non-text files skipped", ga.formatNumber(result.SkippedBinary)})
+	}
+	if result.SkippedUntracked > 0 {
+		table.Append([]string{"Untracked files skipped", ga.formatNumber(result.SkippedUntracked)})
+	}
+	if result.SkippedStale > 0 {
+		table.Append([]string{"Stale files skipped", ga.formatNumber(result.SkippedStale)})
+	}
+}
+
 // getTotalUserLines calculates total lines for user contributions
 func (result *AnalysisResult) getTotalUserLines() int {
 	total := 0
@@ -768,21 +2676,32 @@ func (result *AnalysisResult) getTotalUserLines() int {
 	return total
 }
 
-// Logging methods
-func (ga *GitAnalyzer) logInfo(format string, args ...any) {
-	if !ga.config.Quiet {
-		fmt.Printf("[INFO] "+format+"\n", args...)
+// rankStyle returns the configured rank style, defaulting to medals when
+// emoji output is enabled
+func (ga *GitAnalyzer) rankStyle() RankStyle {
+	if ga.config.RankStyle != "" {
+		return ga.config.RankStyle
 	}
+	if ga.config.IncludeEmoji {
+		return RankMedals
+	}
+	return RankNumeric
 }
 
-func (ga *GitAnalyzer) logWarn(format string, args ...any) {
-	if !ga.config.Quiet {
-		fmt.Printf("%s "+format+"\n", append([]any{warningStyle.Render("[WARN]")}, args...)...)
+// rankLabel returns the rank column value for the author at index i
+func (ga *GitAnalyzer) rankLabel(i int) string {
+	var symbols []string
+	switch ga.rankStyle() {
+	case RankMedals:
+		symbols = defaultMedals
+	case RankCustom:
+		symbols = ga.config.RankSymbols
 	}
-}
 
-func (ga *GitAnalyzer) logError(format string, args ...any) {
-	fmt.Printf("%s "+format+"\n", append([]any{errorStyle.Render("[ERROR]")}, args...)...)
+	if i < len(symbols) {
+		return symbols[i]
+	}
+	return strconv.Itoa(i + 1)
 }
 
 // TODO:
@@ -793,31 +2712,175 @@ func (ga *GitAnalyzer) styleHeader(text string) string {
 	return headerStyle.Render(text)
 }
 
-// formatNumber formats a number with commas
-func formatNumber(n int) string {
-	str := strconv.Itoa(n)
-	if len(str) <= 3 {
-		return str
+// formatNumber formats a number with the locale's thousands separator
+func (ga *GitAnalyzer) formatNumber(n int) string {
+	return ga.printer.Sprintf("%d", n)
+}
+
+// formatPercent formats a percentage with the locale's decimal separator,
+// with the given decimal places unless --precision is set
+func (ga *GitAnalyzer) formatPercent(pct float64, decimals int) string {
+	return ga.printer.Sprintf("%.*f%%", ga.decimals(decimals), pct)
+}
+
+// Run executes the analysis and displays the results
+func (ga *GitAnalyzer) Run(ctx context.Context) error {
+	result, err := ga.Analyze(ctx)
+	if err != nil {
+		return err
+	}
+
+	if result.TotalFiles == 0 && result.DiffBase == "" {
+		return nil
+	}
+
+	if err := ga.displayResults(result); err != nil {
+		return err
+	}
+
+	if err := ga.displaySubmodules(result); err != nil {
+		return err
+	}
+
+	return ga.alsoWrite(result)
+}
+
+// Analyze runs the analysis without displaying anything
+func (ga *GitAnalyzer) Analyze(ctx context.Context) (*AnalysisResult, error) {
+	result, err := ga.analyze(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if ga.config.Anonymize {
+		ga.anonymizeResult(result)
+	}
+	result.SchemaVersion = JSONSchemaVersion
+	if result.Metrics != nil {
+		result.Metrics.setTotals(result)
+	}
+	ga.roundPercentages(result)
+	ga.formatAuthors(result)
+
+	if ga.config.RepoStats && !result.Partial {
+		if result.RepoStats, err = ga.repoStats(ctx); err != nil {
+			ga.logWarn("Failed to summarize the repository's history: %v", err)
+		}
+	}
+
+	if ga.config.DetectAliases && !result.Partial {
+		if result.Aliases, err = ga.detectAliases(ctx); err != nil {
+			ga.logWarn("Failed to detect duplicate authors: %v", err)
+		}
+		switch ga.config.OutputFormat {
+		case FormatTable, FormatJSON:
+		default:
+			// Keep other formats parseable by suggesting on stderr
+			if len(result.Aliases) > 0 {
+				ga.logInfo("Suggested .mailmap entries for likely duplicate authors:")
+				writeMailmap(os.Stderr, result.Aliases)
+			}
+		}
+	}
+
+	if (ga.writes(FormatInflux) || ga.writes(FormatJSONL)) && !result.Partial {
+		// Time-series samples are stamped with the analyzed commit
+		if result.Head, err = ga.headCommit(ctx); err != nil {
+			ga.logWarn("Failed to read HEAD, stamping samples with the current time: %v", err)
+		}
 	}
 
-	var result strings.Builder
-	for i, digit := range str {
-		if i > 0 && (len(str)-i)%3 == 0 {
-			result.WriteString(",")
+	if ga.commands != nil {
+		result.GitCommands = ga.commands.list()
+		if ga.config.OutputFormat != FormatJSON {
+			ga.logCommands(result.GitCommands)
 		}
-		result.WriteRune(digit)
 	}
-	return result.String()
+
+	return result, nil
 }
 
-// Run executes the analysis
-func (ga *GitAnalyzer) Run(ctx context.Context) error {
-	if err := ga.validateDirectory(); err != nil {
-		return err
+// analyze runs the analysis selected by the configuration
+func (ga *GitAnalyzer) analyze(ctx context.Context) (*AnalysisResult, error) {
+	if err := ga.checkGit(ctx); err != nil {
+		return nil, err
+	}
+
+	if err := ga.validateDirectory(ctx); err != nil {
+		return nil, err
 	}
 
 	if err := ga.loadGitignorePatterns(); err != nil {
-		return fmt.Errorf("failed to load .gitignore: %w", err)
+		return nil, fmt.Errorf("failed to load .gitignore: %w", err)
+	}
+
+	if err := ga.loadSubmodules(); err != nil {
+		return nil, fmt.Errorf("failed to load .gitmodules: %w", err)
+	}
+
+	// Blame in a shallow clone attributes every line older than the
+	// cutoff to the boundary commit, so ownership is meaningless
+	shallow := ga.isShallow(ctx)
+	if shallow {
+		if ga.config.FailOnShallow {
+			return nil, errors.New("repository is a shallow clone; fetch the full history with 'git fetch --unshallow'")
+		}
+		ga.logWarn("Repository is a shallow clone: lines older than the available history are attributed to the oldest fetched commit. Run 'git fetch --unshallow' for accurate results.")
+	}
+
+	if !ga.config.NoIgnoreRevs {
+		if path := ga.detectIgnoreRevs(); path != "" {
+			if err := ga.loadIgnoreRevs(ctx, path); err != nil {
+				ga.logWarn("Failed to read %s: %v", IgnoreRevsFileName, err)
+			} else if len(ga.ignoreRevs) > 0 && !ga.config.Quiet {
+				ga.logInfo("Ignoring revisions listed in %s", IgnoreRevsFileName)
+			}
+		}
+	}
+
+	if ga.config.CommitsFrom != "" {
+		if err := ga.loadCommitSet(ctx); err != nil {
+			return nil, fmt.Errorf("failed to read --commits-from: %w", err)
+		}
+	}
+
+	if ga.config.Window != "" {
+		if err := ga.loadWindow(ctx); err != nil {
+			return nil, fmt.Errorf("failed to resolve --window: %w", err)
+		}
+	}
+
+	if ga.config.MergeCase {
+		if err := ga.loadNameCasings(ctx); err != nil {
+			ga.logWarn("Failed to merge author names differing in case: %v", err)
+		}
+	}
+
+	if ga.config.File != "" {
+		result, err := ga.analyzeFile(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to analyze file: %w", err)
+		}
+		result.Shallow = shallow
+		return result, nil
+	}
+
+	if ga.config.DiffBase != "" {
+		result, err := ga.analyzeDiff(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to analyze diff: %w", err)
+		}
+		result.Shallow = shallow
+		return result, nil
+	}
+
+	if ga.config.AllBranches {
+		result, err := ga.analyzeAllBranches(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to analyze branches: %w", err)
+		}
+		result.Shallow = shallow
+		return result, nil
 	}
 
 	if !ga.config.Quiet {
@@ -828,31 +2891,83 @@ func (ga *GitAnalyzer) Run(ctx context.Context) error {
 		}
 	}
 
-	files, err := ga.findFiles()
+	if ga.config.Explain != "" {
+		closeExplain, err := ga.openExplain()
+		if err != nil {
+			return nil, err
+		}
+		defer closeExplain()
+	}
+
+	files, err := ga.findFiles(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to find files: %w", err)
+		return nil, fmt.Errorf("failed to find files: %w", err)
+	}
+	files = ga.dedupeCaseCollisions(files)
+
+	if ga.config.ChangedSince != "" {
+		if files, err = ga.filterChanged(ctx, files); err != nil {
+			return nil, fmt.Errorf("failed to list changed files: %w", err)
+		}
+	}
+
+	if len(ga.config.Languages) > 0 {
+		files = ga.filterByLanguage(ctx, files)
 	}
 
 	if !ga.config.Quiet {
-		ga.logInfo("Found %s files to analyze", formatNumber(len(files)))
+		ga.logInfo("Found %s files to analyze", ga.formatNumber(len(files)))
+	}
+
+	population := len(files)
+	files = ga.sampleFiles(files)
+	sampled := len(files) < population
+	if sampled && !ga.config.Quiet {
+		ga.logWarn("Analyzing a random sample of %s of %s files; results are estimates",
+			ga.formatNumber(len(files)), ga.formatNumber(population))
 	}
 
 	if len(files) == 0 {
 		ga.logWarn("No files found to analyze")
-		return nil
+		return &AnalysisResult{
+			Repository:  ga.config.Directory,
+			GeneratedAt: time.Now(),
+			Shallow:     shallow,
+		}, nil
 	}
 
 	result, err := ga.processFiles(ctx, files)
 	if err != nil {
-		return fmt.Errorf("failed to process files: %w", err)
+		return nil, fmt.Errorf("failed to process files: %w", err)
+	}
+	result.Shallow = shallow
+	if result.Partial {
+		// Anything further would need git, and the context is done
+		return result, nil
+	}
+	if ga.config.FollowRenames && ga.config.Username != "" {
+		ga.addHistory(ctx, result.UserContributions)
+	}
+	if sampled {
+		result.extrapolate(population)
+	}
+
+	if ga.config.RecurseSubmodules {
+		result.Submodules = ga.analyzeSubmodules(ctx)
 	}
 
-	return ga.displayResults(result)
+	return result, nil
 }
 
 // CLI setup
 func main() {
 	var config Config
+	var maxFileSize string
+	var activeSince string
+	var modifiedWithin string
+	var dateFormat string
+	var csvDelimiter string
+	var weightStrategies []string
 
 	rootCmd := &cobra.Command{
 		Use:     "gala [directory] [username]",
@@ -867,6 +2982,17 @@ func main() {
 				config.Directory = "."
 			}
 
+			configUsed, err := loadConfig(cmd, config)
+			if err != nil {
+				return &ExitError{Code: ExitCodeUsage, Err: err}
+			}
+			if configUsed != "" && !config.Quiet {
+				fmt.Fprintf(os.Stderr, "Using config file: %s\n", configUsed)
+			}
+
+			// Keep stderr machine-readable when errors are reported as JSON
+			cmd.SilenceUsage = config.ErrorFormat == ErrorFormatJSON
+
 			if len(args) >= 2 {
 				config.Username = args[1]
 			}
@@ -877,8 +3003,342 @@ func main() {
 			}
 			config.Directory = absPath
 
+			if _, err := resolveLocale(config.Locale); err != nil {
+				return &ExitError{Code: ExitCodeUsage, Err: err}
+			}
+
+			switch config.ErrorFormat {
+			case ErrorFormatText, ErrorFormatJSON:
+			default:
+				return usageErrorf("invalid --error-format %q (expected text or json)", config.ErrorFormat)
+			}
+
+			switch config.RankStyle {
+			case "", RankNone, RankMedals, RankNumeric:
+			case RankCustom:
+				if len(config.RankSymbols) == 0 {
+					return usageErrorf("--rank-style custom requires --rank-symbols or rank-symbols in the config file")
+				}
+			default:
+				return usageErrorf("invalid --rank-style %q (expected none, medals, numeric or custom)", config.RankStyle)
+			}
+
+			switch config.Credit {
+			case CreditAuthor, CreditCommitter:
+			default:
+				return usageErrorf("invalid --credit %q (expected author or committer)", config.Credit)
+			}
+
+			switch config.TimeBasis {
+			case TimeAuthor, TimeCommitter:
+			default:
+				return usageErrorf("invalid --time-basis %q (expected author or committer)", config.TimeBasis)
+			}
+
+			switch config.CountMode {
+			case CountPhysical, CountCode:
+			default:
+				return usageErrorf("invalid --count-mode %q (expected physical or code)", config.CountMode)
+			}
+
+			switch config.CreditMoves {
+			case MovesOriginal, MovesPhysical, MovesProportional:
+			default:
+				return usageErrorf("invalid --credit-moves %q (expected original, physical or proportional)", config.CreditMoves)
+			}
+
+			if !cmd.Flags().Changed("git-path") {
+				if gitPath := os.Getenv("GALA_GIT_PATH"); gitPath != "" {
+					config.GitPath = gitPath
+				}
+			}
+			for _, override := range config.GitConfig {
+				if key, _, ok := strings.Cut(override, "="); !ok || key == "" {
+					return usageErrorf("invalid --git-config %q (expected key=value)", override)
+				}
+			}
+
+			if err := viper.UnmarshalKey("weights", &config.Weights); err != nil {
+				return usageErrorf("invalid weights in config: %w", err)
+			}
+			if err := compileWeights(config.Weights); err != nil {
+				return usageErrorf("invalid weights in config: %w", err)
+			}
+			// loadConfig sets flags from the config file and environment
+			// without marking them changed
+			if cmd.Flags().Changed("weight") || viper.IsSet("weight") {
+				if config.WeightStrategies, err = parseWeightStrategies(weightStrategies); err != nil {
+					return usageErrorf("invalid --weight: %w", err)
+				}
+			} else if len(config.Weights) > 0 {
+				config.WeightStrategies = []WeightStrategy{WeightPathGlob}
+			}
+			if slices.Contains(config.WeightStrategies, WeightPathGlob) && len(config.Weights) == 0 {
+				return usageErrorf("--weight path-glob requires a weights section in the config file")
+			}
+			if config.DecayHalfLife <= 0 {
+				return usageErrorf("--decay-half-life must be positive")
+			}
+			if config.Weighted && len(config.WeightStrategies) == 0 {
+				return usageErrorf("--weighted requires --weight or a weights section in the config file")
+			}
+
+			if err := viper.UnmarshalKey("teams", &config.Teams); err != nil {
+				return usageErrorf("invalid teams in config: %w", err)
+			}
+			if config.ByTeam && len(config.Teams) == 0 {
+				return usageErrorf("--by-team requires a teams section in the config file")
+			}
+
+			if err := validateFields(config.Fields); err != nil {
+				return usageErrorf("invalid --fields: %w", err)
+			}
+			if _, err := parseCSVColumns(config.CSVColumns); err != nil {
+				return usageErrorf("invalid --csv-columns: %w", err)
+			}
+			if dateFormat != "" {
+				layout, err := parseDateFormat(dateFormat)
+				if err != nil {
+					return usageErrorf("invalid --date-format %q: %w", dateFormat, err)
+				}
+				config.DateLayout = layout
+			}
+
+			if config.LineRange != "" {
+				if config.File == "" {
+					return usageErrorf("--lines requires --file")
+				}
+				if _, _, err := parseLineRange(config.LineRange); err != nil {
+					return usageErrorf("invalid --lines: %w", err)
+				}
+			}
+			if config.File != "" && config.DiffBase != "" {
+				return usageErrorf("--file and --diff cannot be combined")
+			}
+			if strings.HasPrefix(config.DiffBase, "-") {
+				// git would take it for an option
+				return usageErrorf("invalid --diff %q (expected a commit, branch or tag)", config.DiffBase)
+			}
+			if config.ChangedSince != "" && (config.File != "" || config.DiffBase != "" || config.AllBranches) {
+				return usageErrorf("--changed-since cannot be combined with --file, --diff or --all-branches")
+			}
+			if strings.HasPrefix(config.ChangedSince, "-") {
+				return usageErrorf("invalid --changed-since %q (expected a commit, branch or tag)", config.ChangedSince)
+			}
+
+			if config.Sample < 0 {
+				return usageErrorf("invalid --sample: must not be negative")
+			}
+			if config.Window != "" {
+				if _, _, err := parseWindow(config.Window); err != nil {
+					return usageErrorf("invalid --window %q (%w)", config.Window, err)
+				}
+			}
+			if config.Tiers {
+				switch {
+				case config.Username != "" || config.DiffBase != "" || config.File != "" || config.ByTeam:
+					return usageErrorf("--tiers cannot be combined with a username, --diff, --file or --by-team")
+				case len(config.TierThresholds) != 2:
+					return usageErrorf("invalid --tier-thresholds: expected two percentages, such as 10,1")
+				case config.TierThresholds[0] <= config.TierThresholds[1] || config.TierThresholds[1] <= 0 || config.TierThresholds[0] > 100:
+					return usageErrorf("invalid --tier-thresholds %g,%g (expected a core threshold above the occasional one, both from 0 to 100)",
+						config.TierThresholds[0], config.TierThresholds[1])
+				}
+			}
+			if config.DirDepth < 0 {
+				return usageErrorf("invalid --dir-depth %d (expected 1 or more)", config.DirDepth)
+			}
+			if config.DirDepth > 0 && (config.Username != "" || config.DiffBase != "" || config.File != "" ||
+				config.AllBranches || config.ByTeam || config.Tiers) {
+				return usageErrorf("--dir-depth cannot be combined with a username, --diff, --file, --all-branches, --by-team or --tiers")
+			}
+			if config.OrphanThreshold < 0 || config.OrphanThreshold > 100 {
+				return usageErrorf("invalid --orphan-threshold %g (expected a percentage from 0 to 100)", config.OrphanThreshold)
+			}
+			if config.OrphanThreshold > 0 && (config.File != "" || config.DiffBase != "" || config.AllBranches) {
+				return usageErrorf("--orphan-threshold cannot be combined with --file, --diff or --all-branches")
+			}
+			if config.Precision < defaultPrecision || config.Precision > maxPrecision {
+				return usageErrorf("invalid --precision %d (expected 0 to %d)", config.Precision, maxPrecision)
+			}
+			if config.Sample > 0 && (config.File != "" || config.DiffBase != "") {
+				return usageErrorf("--sample cannot be combined with --file or --diff")
+			}
+
+			if config.FollowRenames && config.File == "" && config.Username == "" {
+				return usageErrorf("--follow-renames requires --file or a username")
+			}
+
+			if config.AllBranches {
+				switch {
+				case config.File != "" || config.DiffBase != "":
+					return usageErrorf("--all-branches cannot be combined with --file or --diff")
+				case config.Username != "":
+					return usageErrorf("--all-branches cannot be combined with a username")
+				case config.Sample > 0 || config.Weighted || config.ByTeam:
+					return usageErrorf("--all-branches cannot be combined with --sample, --weighted or --by-team")
+				}
+			}
+
+			switch config.TableStyle {
+			case TableDefault, TableRounded, TableMarkdown, TableBorderless:
+			default:
+				return usageErrorf("invalid --table-style %q (expected default, rounded, markdown or borderless)", config.TableStyle)
+			}
+
+			if config.AnonymizeSalt != "" {
+				config.Anonymize = true
+			}
+			if config.Anonymize && config.AnonymizeSalt == "" {
+				salt, err := newAnonymizeSalt()
+				if err != nil {
+					return fmt.Errorf("failed to generate --anonymize salt: %w", err)
+				}
+				config.AnonymizeSalt = salt
+			}
+			if config.Anonymize && config.DetectAliases {
+				return usageErrorf("--detect-aliases can't be combined with --anonymize, since suggestions show names and emails")
+			}
+			template, err := authorTemplate(config.AuthorFormat)
+			if err != nil {
+				return usageErrorf("invalid --author-format %q: %w", config.AuthorFormat, err)
+			}
+			if config.Anonymize && template != "{name}" {
+				return usageErrorf("--author-format can't be combined with --anonymize, since emails and handles identify authors")
+			}
+			if config.HandlesFile != "" || strings.Contains(template, "{handle}") {
+				config.ResolveHandles = true
+			}
+			if config.Anonymize && config.ResolveHandles {
+				return usageErrorf("--resolve-handles can't be combined with --anonymize, since handles identify authors")
+			}
+
+			if _, err := parseLabels(config.Labels); err != nil {
+				return usageErrorf("invalid --label: %w", err)
+			}
+
+			if strings.TrimSpace(config.UnknownLabel) == "" {
+				return usageErrorf("invalid --unknown-label: must not be empty")
+			}
+
+			if config.PlainDelimiter == "" {
+				return usageErrorf("invalid --plain-delimiter: must not be empty")
+			}
+
+			if !cmd.Flags().Changed("bot-pattern") && viper.IsSet("bot-patterns") {
+				config.BotPatterns = viper.GetStringSlice("bot-patterns")
+			}
+			if _, err := compileBotPatterns(config.BotPatterns); err != nil {
+				return usageErrorf("invalid --bot-pattern: %w", err)
+			}
+			if config.MergeBots && config.ExcludeBots {
+				return usageErrorf("--merge-bots and --exclude-bots cannot be combined")
+			}
+
+			if _, err := compileRegexes(config.ExcludePathRegex); err != nil {
+				return usageErrorf("invalid --exclude-path-regex: %w", err)
+			}
+			if _, err := compileRegexes(config.IncludePathRegex); err != nil {
+				return usageErrorf("invalid --include-path-regex: %w", err)
+			}
+
+			if config.SortBy == SortByScore {
+				config.Score = true
+			} else if config.Score && !cmd.Flags().Changed("sort") && !viper.IsSet("sort") {
+				config.SortBy = SortByScore
+			}
+			if config.Score {
+				config.ScoreWeights = defaultScoreWeights
+				if err := viper.UnmarshalKey("score", &config.ScoreWeights); err != nil {
+					return usageErrorf("invalid score in config: %w", err)
+				}
+				if err := config.ScoreWeights.validate(); err != nil {
+					return usageErrorf("invalid score in config: %w", err)
+				}
+			}
+
+			if config.LogLevel != "" {
+				var level slog.Level
+				if err := level.UnmarshalText([]byte(config.LogLevel)); err != nil {
+					return usageErrorf("invalid --log-level %q (expected debug, info, warn or error)", config.LogLevel)
+				}
+			}
+			switch config.LogFormat {
+			case LogFormatText, LogFormatJSON:
+			default:
+				return usageErrorf("invalid --log-format %q (expected text or json)", config.LogFormat)
+			}
+
+			if _, ok := formatters[config.OutputFormat]; !ok {
+				return usageErrorf("invalid --output %q (expected %s)", config.OutputFormat, strings.Join(formatterNames(), ", "))
+			}
+			if config.AlsoWrite != "" && config.AlsoWriteFormat == "" {
+				format, ok := formatForPath(config.AlsoWrite)
+				if !ok {
+					return usageErrorf("can't infer the format of --also-write %q from its extension; set --also-write-format", config.AlsoWrite)
+				}
+				config.AlsoWriteFormat = format
+			}
+			if _, ok := formatters[config.AlsoWriteFormat]; config.AlsoWrite != "" && !ok {
+				return usageErrorf("invalid --also-write-format %q (expected %s)", config.AlsoWriteFormat, strings.Join(formatterNames(), ", "))
+			}
+			if config.DetailedJSON {
+				if config.OutputFormat != FormatJSON && (config.AlsoWrite == "" || config.AlsoWriteFormat != FormatJSON) {
+					return usageErrorf("--detailed-json requires JSON output, with --output json or a JSON --also-write file")
+				}
+				if config.File != "" || config.DiffBase != "" || config.AllBranches {
+					return usageErrorf("--detailed-json cannot be combined with --file, --diff or --all-branches")
+				}
+			}
+
+			delimiter, err := parseDelimiter(csvDelimiter)
+			if err != nil {
+				return usageErrorf("invalid --csv-delimiter: %w", err)
+			}
+			config.CSVDelimiter = delimiter
+
+			if config.ModifiedMtime && modifiedWithin == "" {
+				return usageErrorf("--modified-by-mtime requires --modified-within")
+			}
+			if modifiedWithin != "" && (config.File != "" || config.DiffBase != "" || config.AllBranches) {
+				return usageErrorf("--modified-within cannot be combined with --file, --diff or --all-branches")
+			}
+
+			if maxFileSize != "" {
+				size, err := parseSize(maxFileSize)
+				if err != nil {
+					return &ExitError{Code: ExitCodeUsage, Err: err}
+				}
+				config.MaxFileSize = size
+			}
+
+			// Thousands of rows flood a terminal and are slow to render, so
+			// the author table is cut short unless a limit was asked for
+			if config.MaxResults == 0 && !cmd.Flags().Changed("limit") && !viper.IsSet("limit") &&
+				config.OutputFormat == FormatTable && config.AlsoWrite == "" &&
+				config.Username == "" && config.DiffBase == "" && config.File == "" &&
+				isTerminal(os.Stdout) {
+				config.MaxResults = terminalLimit
+				config.ImplicitLimit = true
+			}
+
 			analyzer := NewGitAnalyzer(config)
 
+			if activeSince != "" {
+				cutoff, err := analyzer.resolveDate(cmd.Context(), activeSince)
+				if err != nil {
+					return usageErrorf("invalid --active-since %q: %w", activeSince, err)
+				}
+				analyzer.config.ActiveSince = cutoff
+			}
+			if modifiedWithin != "" {
+				cutoff, err := analyzer.resolveDate(cmd.Context(), modifiedWithin)
+				if err != nil {
+					return usageErrorf("invalid --modified-within %q: %w", modifiedWithin, err)
+				}
+				analyzer.config.ModifiedSince = cutoff
+			}
+
 			ctx, cancel := context.WithCancel(context.Background())
 			defer cancel()
 
@@ -887,7 +3347,7 @@ func main() {
 			go func() {
 				<-sigChan
 				if !config.Quiet {
-					fmt.Printf("\nReceived interrupt signal, shutting down gracefully...\n")
+					fmt.Fprintf(os.Stderr, "\nReceived interrupt signal, shutting down gracefully...\n")
 				}
 				cancel()
 			}()
@@ -896,41 +3356,221 @@ func main() {
 		},
 	}
 
+	// Flags default to flagDefaults, like the subcommands' analyses
+	defaults := flagDefaults()
+
 	// Output options
-	rootCmd.Flags().StringVarP((*string)(&config.OutputFormat), "output", "o", "table",
-		"Output format: table, json, csv, plain")
-	rootCmd.Flags().StringVar((*string)(&config.SortBy), "sort", "lines",
-		"Sort by: lines, name, files")
+	rootCmd.Flags().StringVarP((*string)(&config.OutputFormat), "output", "o", string(defaults.OutputFormat),
+		"Output format: table, json, csv, plain, dot, influx, jsonl")
+	rootCmd.Flags().StringVar(&config.AlsoWrite, "also-write", "",
+		"Also write the results to this file, in the format its extension implies (e.g. report.json), gzip-compressed with a .gz suffix")
+	rootCmd.Flags().StringVar((*string)(&config.AlsoWriteFormat), "also-write-format", "",
+		"Format of the --also-write file (default: from its extension)")
+	rootCmd.Flags().StringVar((*string)(&config.SortBy), "sort", string(defaults.SortBy),
+		"Sort by: lines, name, files, score (default: score with --score)")
+	rootCmd.Flags().Float64Var(&config.OrphanThreshold, "orphan-threshold", 0,
+		"List files whose top author owns less than this percentage of their lines")
+	rootCmd.Flags().IntVar(&config.Precision, "precision", defaults.Precision,
+		"Decimal places of percentages in every output format, JSON included (default: 1 in tables, 2 in CSV and plain, full in JSON)")
 	rootCmd.Flags().IntVar(&config.MaxResults, "limit", 0,
 		"Limit number of results (0 = no limit)")
+	rootCmd.Flags().BoolVar(&config.NoOthers, "no-others", false,
+		"Don't summarize authors cut off by --limit in an \"Others\" row")
+	rootCmd.Flags().StringSliceVar(&config.Fields, "fields", nil,
+		"Author fields to include in JSON output, e.g. name,line_count,percentage")
+	rootCmd.Flags().BoolVar(&config.DetailedJSON, "detailed-json", false,
+		"List each author's files and line counts in JSON output (large on big repositories)")
+	rootCmd.Flags().StringSliceVar(&config.CSVColumns, "csv-columns", nil,
+		"Author columns of CSV output in order, optionally renamed as field=Header (default: name,line_count,file_count,percentage)")
+	rootCmd.Flags().StringVar(&dateFormat, "date-format", "",
+		"Layout of displayed dates: iso, rfc3339, us, eu, short, long or a Go time layout such as 02.01.2006 (JSON keeps YYYY-MM-DD)")
 	rootCmd.Flags().BoolVar(&config.IncludeEmoji, "emoji", false,
 		"Include emoji in output")
+	rootCmd.Flags().BoolVar(&config.RichSummary, "rich-summary", false,
+		"Add highlights to the summary: most active author, largest directory, dominant language, oldest and newest lines")
+	rootCmd.Flags().BoolVar(&config.RepoStats, "repo-stats", false,
+		"Show the repository's first and last commit dates, commit count and commits per week ahead of the results")
+	rootCmd.Flags().BoolVar(&config.ShowCommits, "show-commits", false,
+		"Show the number of distinct commits behind each author's surviving lines")
+	rootCmd.Flags().BoolVar(&config.ShowAverage, "show-avg", false,
+		"Show each author's average lines per file as a column")
+	rootCmd.Flags().BoolVar(&config.ShowPercentile, "show-percentile", false,
+		"Show each author's percentile rank (top N%) as a column")
+	rootCmd.Flags().StringVar((*string)(&config.RankStyle), "rank-style", "",
+		"Rank decoration: none, medals, numeric, custom (default: medals with --emoji, else numeric)")
+	rootCmd.Flags().StringSliceVar(&config.RankSymbols, "rank-symbols", nil,
+		"Symbols for the top ranks with --rank-style custom")
+	rootCmd.Flags().BoolVar(&config.Anonymize, "anonymize", false,
+		"Replace author names with salted hashes such as author-1a2b3c4d in all output")
+	rootCmd.Flags().StringVar(&config.AnonymizeSalt, "anonymize-salt", "",
+		"Secret salt for --anonymize, keeping identifiers stable across runs (implies --anonymize; default: random per run)")
+	rootCmd.Flags().StringArrayVar(&config.Labels, "label", nil,
+		"Dimension key=value stamped on every influx or jsonl record, e.g. tag=v1.2 (repeatable)")
+	rootCmd.Flags().StringVar((*string)(&config.TableStyle), "table-style", string(defaults.TableStyle),
+		"Table borders: default, rounded, markdown, borderless")
+	rootCmd.Flags().BoolVar(&config.CSVBOM, "csv-bom", false,
+		"Start CSV output with a UTF-8 byte order mark for Excel")
+	rootCmd.Flags().StringVar(&config.PlainDelimiter, "plain-delimiter", defaults.PlainDelimiter,
+		"Plain output column separator: tab, space, aligned (padded columns), or a custom string")
+	rootCmd.Flags().StringVar(&csvDelimiter, "csv-delimiter", string(defaults.CSVDelimiter),
+		"CSV field delimiter: a character, or comma, semicolon, tab, pipe")
+	rootCmd.Flags().StringVar(&config.Locale, "locale", "",
+		"Locale for number formatting, e.g. en-US, de-DE (default: $LANG)")
 
 	// Filtering options
-	rootCmd.Flags().IntVar(&config.MinLines, "min-lines", 1,
+	rootCmd.Flags().BoolVar(&config.AllBranches, "all-branches", false,
+		"Count lines surviving on any local or remote branch, each line once (slow)")
+	rootCmd.Flags().IntVar(&config.Sample, "sample", 0,
+		"Estimate from a random sample of this many files (0 = analyze all files)")
+	rootCmd.Flags().Int64Var(&config.Seed, "seed", 0,
+		"Random seed for --sample, for reproducible estimates (0 = random)")
+	rootCmd.Flags().BoolVar(&config.Weighted, "weighted", false,
+		"Sort and compute percentages by weighted lines")
+	rootCmd.Flags().StringSliceVar(&weightStrategies, "weight", nil,
+		"Weighting strategies multiplied per line: path-glob, recency-decay, file-importance (default: path-glob with a weights section)")
+	rootCmd.Flags().Float64Var(&config.DecayHalfLife, "decay-half-life", defaults.DecayHalfLife,
+		"Age in days at which the recency-decay weight halves")
+	rootCmd.Flags().BoolVar(&config.ByTeam, "by-team", false,
+		"Aggregate lines by the teams defined in the config file")
+	rootCmd.Flags().BoolVar(&config.PartialResults, "partial-on-interrupt", false,
+		"On Ctrl-C, show the results of the files processed so far, marked as partial")
+	rootCmd.Flags().StringVar(&config.Window, "window", "",
+		"Count only lines dated within a window of the history: first-year, last-year, first-<days>d or last-<days>d")
+	rootCmd.Flags().BoolVar(&config.FollowSymlinks, "follow-symlinks", false,
+		"Descend into symlinked directories that lead elsewhere inside the analyzed directory")
+	rootCmd.Flags().IntVar(&config.DirDepth, "dir-depth", 0,
+		"Roll lines up into directories this many levels deep, listing each directory's top authors")
+	rootCmd.Flags().BoolVar(&config.Tiers, "tiers", false,
+		"Group authors into core, regular and occasional contributors by percentage")
+	rootCmd.Flags().Float64SliceVar(&config.TierThresholds, "tier-thresholds", defaults.TierThresholds,
+		"Percentages above which authors are core contributors and below which they are occasional")
+	rootCmd.Flags().BoolVar(&config.NoIgnoreRevs, "no-ignore-revs", false,
+		"Don't skip the commits listed in .git-blame-ignore-revs or blame.ignoreRevsFile")
+	rootCmd.Flags().BoolVar(&config.FailOnShallow, "fail-on-shallow", false,
+		"Exit with an error instead of a warning when the repository is a shallow clone")
+	rootCmd.Flags().StringVar(&activeSince, "active-since", "",
+		"Only show authors with a line authored on or after this date (YYYY-MM-DD, an age such as 90d or e.g. \"6 months ago\")")
+	rootCmd.Flags().StringVar(&modifiedWithin, "modified-within", "",
+		"Skip files whose last commit is older than this age or date (90d, 6m, YYYY-MM-DD or e.g. \"6 months ago\")")
+	rootCmd.Flags().BoolVar(&config.ModifiedMtime, "modified-by-mtime", false,
+		"Judge --modified-within by filesystem modification times instead of commit dates")
+	rootCmd.Flags().BoolVar(&config.DetectAliases, "detect-aliases", false,
+		"Suggest .mailmap entries for authors appearing under several names or emails (counts are unchanged)")
+	rootCmd.Flags().StringVar(&config.AuthorFormat, "author-format", defaults.AuthorFormat,
+		"How authors are shown: name, name-email, email, name-handle, or a template such as \"{name} <{email}>\"")
+	rootCmd.Flags().BoolVar(&config.ResolveHandles, "resolve-handles", false,
+		"Show authors' GitHub handles from "+HandlesFileName+" or GitHub noreply emails")
+	rootCmd.Flags().StringVar(&config.HandlesFile, "handles-file", "",
+		"Mapping file of emails or names to GitHub handles (implies --resolve-handles)")
+	rootCmd.Flags().StringVar((*string)(&config.CreditMoves), "credit-moves", string(defaults.CreditMoves),
+		"Credit lines copied between files to their first author (original), the copying commit (physical), or split between the copies (proportional)")
+	rootCmd.Flags().StringVar((*string)(&config.CountMode), "count-mode", string(defaults.CountMode),
+		"Lines to count: physical (every line) or code (skip blank lines and full-line comments; approximate)")
+	rootCmd.Flags().BoolVar(&config.DedupeLines, "dedupe-identical-lines", false,
+		"Count each distinct line content once per author and file")
+	rootCmd.Flags().StringVar((*string)(&config.Credit), "credit", string(defaults.Credit),
+		"Credit lines to the commit's author or committer")
+	rootCmd.Flags().StringVar((*string)(&config.TimeBasis), "time-basis", string(defaults.TimeBasis),
+		"Date lines by when they were written (author) or landed (committer)")
+	rootCmd.Flags().BoolVar(&config.Score, "score", false,
+		"Rank authors by a contribution score combining lines, files and recency")
+	rootCmd.Flags().IntVar(&config.MinLines, "min-lines", defaults.MinLines,
 		"Minimum lines threshold for inclusion")
+	rootCmd.Flags().IntVar(&config.MinFiles, "min-files", 0,
+		"Minimum number of files an author must have lines in for inclusion")
 	rootCmd.Flags().StringSliceVar(&config.ExcludeAuthor, "exclude-author", nil,
 		"Exclude specific authors")
+	rootCmd.Flags().BoolVar(&config.MergeCase, "merge-case-insensitive", false,
+		"Merge author names differing only in case, shown in their most common casing")
+	rootCmd.Flags().BoolVar(&config.MergeBots, "merge-bots", false,
+		"Aggregate bot accounts into a single \"Bots\" entry")
+	rootCmd.Flags().BoolVar(&config.ExcludeBots, "exclude-bots", false,
+		"Exclude bot accounts")
+	rootCmd.Flags().StringSliceVar(&config.BotPatterns, "bot-pattern", nil,
+		"Regular expressions identifying bot authors (default: [bot] suffix and common bots)")
+	rootCmd.Flags().StringVar(&config.UnknownLabel, "unknown-label", defaults.UnknownLabel,
+		"Name to report lines from commits without an author name under")
+	rootCmd.Flags().BoolVar(&config.ExcludeUnknown, "exclude-unknown", false,
+		"Exclude lines from commits without an author name")
 	rootCmd.Flags().StringSliceVar(&config.IncludeAuthor, "include-author", nil,
 		"Include only specific authors")
+	rootCmd.Flags().BoolVar(&config.NoTrimTotal, "no-trim-total", false,
+		"Compute percentages against all lines, including excluded authors")
 	rootCmd.Flags().StringVar(&config.DateSince, "since", "",
-		"Only count lines since date (YYYY-MM-DD)")
+		"Only count lines since date (YYYY-MM-DD or any git date, e.g. \"6 months ago\")")
 	rootCmd.Flags().StringVar(&config.DateUntil, "until", "",
-		"Only count lines until date (YYYY-MM-DD)")
+		"Only count lines until date (YYYY-MM-DD or any git date, e.g. \"last.month\")")
+	rootCmd.Flags().StringArrayVar(&config.ExcludePathRegex, "exclude-path-regex", nil,
+		"Exclude files whose relative path matches this regular expression (repeatable)")
+	rootCmd.Flags().StringArrayVar(&config.IncludePathRegex, "include-path-regex", nil,
+		"Only analyze files whose relative path matches this regular expression (repeatable)")
+	rootCmd.Flags().BoolVar(&config.NoDefaultExcludes, "no-default-excludes", false,
+		"Don't exclude the built-in file types (images, lock files, archives, ...)")
+	rootCmd.Flags().StringSliceVar(&config.Languages, "language", nil,
+		"Only analyze files of these languages, e.g. go,python (honors linguist-language in .gitattributes)")
 	rootCmd.Flags().StringSliceVar(&config.ExtraPatterns, "exclude-pattern", nil,
 		"Additional file patterns to exclude")
+	rootCmd.Flags().BoolVar(&config.RecurseSubmodules, "recurse-submodules", false,
+		"Analyze each git submodule separately and report its results")
+	rootCmd.Flags().StringVar(&config.Explain, "explain", "",
+		"Trace why each file is included or excluded, on stderr or with --explain=FILE in a file")
+	rootCmd.Flags().Lookup("explain").NoOptDefVal = explainStderr
+	rootCmd.Flags().BoolVar(&config.UseGitLsFiles, "use-git-ls-files", false,
+		"List files with git ls-files instead of walking the directory (faster on network filesystems; tracked files only)")
+	rootCmd.Flags().BoolVar(&config.ExcludeVendor, "exclude-vendored", false,
+		"Skip dependency directories detected from package manifests")
+	rootCmd.Flags().BoolVar(&config.FollowRenames, "follow-renames", false,
+		"With --file or a username, also credit changes over each file's whole history, following renames")
+	rootCmd.Flags().BoolVar(&config.TextOnly, "text-only", false,
+		"Only analyze files whose content is detected as text, whatever their extension")
+	rootCmd.Flags().BoolVar(&config.IncludeLFS, "include-lfs", false,
+		"Analyze Git LFS pointer files instead of skipping them")
+	rootCmd.Flags().StringVar(&maxFileSize, "max-file-size", "",
+		"Skip files larger than this size, e.g. 5MB (KB, MB, GB are powers of 1000; K, M, G and KiB, MiB, GiB of 1024) (default: no limit)")
+	rootCmd.Flags().StringVar(&config.File, "file", "",
+		"Analyze a single file (relative to the directory) instead of the whole repository")
+	rootCmd.Flags().StringVar(&config.LineRange, "lines", "",
+		"Restrict --file to a line range, e.g. 100-250")
+	rootCmd.Flags().StringVar(&config.DiffBase, "diff", "",
+		"Report prior owners of the lines changed since a base ref")
+	rootCmd.Flags().StringVar(&config.ChangedSince, "changed-since", "",
+		"Only analyze the files changed since the branch forked from this ref, blaming them whole")
 
 	// Behavior options
 	rootCmd.Flags().IntVarP(&config.Concurrency, "concurrency", "c", 0,
 		"Number of concurrent processes (default: 2*CPU cores)")
+	rootCmd.Flags().StringVar(&config.LogLevel, "log-level", "",
+		"Diagnostics level on stderr: debug, info, warn, error (default: info, debug with --verbose)")
+	rootCmd.Flags().StringVar(&config.LogFormat, "log-format", defaults.LogFormat,
+		"Diagnostics format on stderr: text, json")
 	rootCmd.Flags().BoolVarP(&config.Verbose, "verbose", "v", false,
 		"Enable verbose output")
 	rootCmd.Flags().BoolVarP(&config.Quiet, "quiet", "q", false,
 		"Suppress all output except results")
 	rootCmd.Flags().BoolVar(&config.NoProgress, "no-progress", false,
 		"Disable progress bar")
+	rootCmd.Flags().BoolVar(&config.ReadOnly, "read-only", false,
+		"Never write to the analyzed repository, including git's optional index refreshes")
+	rootCmd.Flags().BoolVar(&config.RetryOnLock, "retry-on-lock", false,
+		"Pause and retry blame calls that fail because another git process holds the index lock")
+	rootCmd.Flags().StringVar(&config.CommitsFrom, "commits-from", "",
+		"Only count lines introduced by the commits listed in this file (\"-\" for stdin), one per line")
+	rootCmd.Flags().BoolVar(&config.Ordered, "ordered", false,
+		"Handle blame results in the order files were found, for reproducible logs (slower)")
+	rootCmd.Flags().BoolVar(&config.Resume, "resume", false,
+		"Resume an interrupted run, skipping files it already processed")
+	rootCmd.Flags().BoolVar(&config.ProgressJSON, "progress-json", false,
+		"Emit progress as JSON lines on stderr instead of a progress bar")
 	rootCmd.Flags().StringVar(&config.ConfigFile, "config", "",
 		"Config file path")
+	rootCmd.Flags().BoolVar(&config.RecordCommands, "record-commands", false,
+		"Record the git commands run, as git_commands in JSON output or logged otherwise")
+	rootCmd.Flags().StringVar(&config.GitPath, "git-path", defaults.GitPath,
+		"Git executable to run (env: GALA_GIT_PATH)")
+	rootCmd.Flags().StringArrayVar(&config.GitConfig, "git-config", nil,
+		"Git configuration override passed as -c key=value (repeatable)")
+	rootCmd.Flags().StringVar((*string)(&config.ErrorFormat), "error-format", string(defaults.ErrorFormat),
+		"Error output format: text, json (json is written to stderr)")
 
 	// Shell completion commands
 	completionCmd := &cobra.Command{
@@ -987,30 +3627,52 @@ PowerShell:
 		},
 	}
 
-	rootCmd.AddCommand(completionCmd)
-
-	// Setup config file support
-	if config.ConfigFile != "" {
-		viper.SetConfigFile(config.ConfigFile)
-	} else {
-		viper.SetConfigName("gala")
-		viper.SetConfigType("yaml")
-		viper.AddConfigPath(".")
-		viper.AddConfigPath("$HOME/.config/gala")
-		viper.AddConfigPath("/etc/gala")
-	}
+	var versionJSON bool
+	versionCmd := &cobra.Command{
+		Use:   "version",
+		Short: "Print version and build information",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			info := BuildInfo{
+				Version:   Version,
+				Commit:    GitCommit,
+				GoVersion: runtime.Version(),
+				Built:     BuildDate,
+			}
 
-	viper.AutomaticEnv()
-	viper.SetEnvPrefix("GALA")
+			if versionJSON {
+				encoder := json.NewEncoder(os.Stdout)
+				encoder.SetIndent("", "  ")
+				return encoder.Encode(info)
+			}
 
-	if err := viper.ReadInConfig(); err == nil && !config.Quiet {
-		fmt.Printf("Using config file: %s\n", viper.ConfigFileUsed())
+			fmt.Printf("%s %s (commit: %s, built: %s, %s)\n",
+				AppName, info.Version, info.Commit, info.Built, info.GoVersion)
+			return nil
+		},
 	}
+	versionCmd.Flags().BoolVar(&versionJSON, "json", false,
+		"Output build information as JSON")
+
+	rootCmd.AddCommand(completionCmd)
+	rootCmd.AddCommand(versionCmd)
+	rootCmd.AddCommand(newCacheCmd())
+	rootCmd.AddCommand(newServeCmd())
+	rootCmd.AddCommand(newBenchmarkCmd())
+	rootCmd.AddCommand(newOwnersCmd())
+	rootCmd.AddCommand(newReportCmd())
+
+	// Errors are reported below in the requested format
+	rootCmd.SilenceErrors = true
+	rootCmd.SetFlagErrorFunc(func(cmd *cobra.Command, err error) error {
+		return &ExitError{Code: ExitCodeUsage, Err: err}
+	})
 
 	// Execute
 	if err := rootCmd.Execute(); err != nil {
-		fmt.Printf("%s %v\n", errorStyle.Render("[ERROR]"), err)
-		os.Exit(1)
+		code := exitCode(err)
+		reportError(config, err, code)
+		os.Exit(code)
 	}
 }
 
@@ -1042,10 +3704,13 @@ Examples:
   gala --exclude-author bot --exclude-pattern "*.generated.go"
 
 Configuration:
-  Gala supports configuration files in YAML format. Place gala.yaml in:
-  - Current directory
-  - ~/.config/gala/
-  - /etc/gala/
+  Gala reads the first configuration file found, in YAML, JSON or TOML:
+  - --config path (or GALA_CONFIG)
+  - gala.yaml, gala.yml, gala.json or gala.toml in the current directory,
+    then .galarc (YAML or JSON), then pyproject.toml with a [tool.gala] table
+  - The same files at the top level of the git repository
+  - ~/.config/gala/gala.{yaml,yml,json,toml}, then ~/.galarc
+  - /etc/gala/gala.{yaml,yml,json,toml}
 
 Environment variables:
   All flags can be set via environment variables with GALA_ prefix:
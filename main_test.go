@@ -0,0 +1,313 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// testRepo is a scratch git repository for tests
+type testRepo struct {
+	t   *testing.T
+	dir string
+}
+
+// newTestRepo creates an empty repository in a temporary directory, with
+// git isolated from the user's and system configuration
+func newTestRepo(t *testing.T) *testRepo {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not found")
+	}
+	t.Setenv("GIT_CONFIG_GLOBAL", os.DevNull)
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := &testRepo{t: t, dir: dir}
+	r.git("init", "-q", "-b", "main")
+	return r
+}
+
+// git runs a git command in the repository and returns its output
+func (r *testRepo) git(args ...string) string {
+	r.t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = r.dir
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		r.t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, output)
+	}
+	return string(output)
+}
+
+// write creates or replaces a file in the working tree
+func (r *testRepo) write(path, content string) {
+	r.t.Helper()
+	full := filepath.Join(r.dir, path)
+	if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+		r.t.Fatal(err)
+	}
+	if err := os.WriteFile(full, []byte(content), 0o644); err != nil {
+		r.t.Fatal(err)
+	}
+}
+
+// commit writes the files and commits them as the given author
+func (r *testRepo) commit(author string, files map[string]string) {
+	r.t.Helper()
+	for path, content := range files {
+		r.write(path, content)
+	}
+	r.git("add", "-A")
+	email := strings.ToLower(strings.Join(strings.Fields(author), ".")) + "@example.com"
+	r.git("-c", "user.name="+author, "-c", "user.email="+email,
+		"commit", "-q", "--allow-empty-message", "-m", "")
+}
+
+// analyze runs the full analysis of the repository
+func (r *testRepo) analyze(configure func(*Config)) *AnalysisResult {
+	r.t.Helper()
+	config := defaultConfig(r.dir)
+	if configure != nil {
+		configure(&config)
+	}
+	result, err := NewGitAnalyzer(config).Analyze(context.Background())
+	if err != nil {
+		r.t.Fatal(err)
+	}
+	return result
+}
+
+// lines returns n numbered lines starting with prefix
+func lines(prefix string, n int) string {
+	var b strings.Builder
+	for i := range n {
+		fmt.Fprintf(&b, "%s %d\n", prefix, i)
+	}
+	return b.String()
+}
+
+// authorLines maps each author in the result to their line count
+func authorLines(result *AnalysisResult) map[string]int {
+	counts := make(map[string]int, len(result.Authors))
+	for _, author := range result.Authors {
+		counts[author.Name] = author.LineCount
+	}
+	return counts
+}
+
+// TestAnalyzePipeline runs the whole pipeline over many files with many
+// workers; run it with -race to check the result aggregation
+func TestAnalyzePipeline(t *testing.T) {
+	r := newTestRepo(t)
+	alice := make(map[string]string)
+	bob := make(map[string]string)
+	for i := range 40 {
+		alice[fmt.Sprintf("src/a%02d.go", i)] = lines("alice", 3)
+		bob[fmt.Sprintf("pkg/b%02d.go", i)] = lines("bob", 2)
+	}
+	r.commit("Alice", alice)
+	r.commit("Bob", bob)
+
+	result := r.analyze(func(c *Config) { c.Concurrency = 16 })
+
+	if result.FilesProcessed != 80 || result.TotalFiles != 80 {
+		t.Errorf("processed %d of %d files, want 80 of 80", result.FilesProcessed, result.TotalFiles)
+	}
+	if result.TotalLines != 200 {
+		t.Errorf("TotalLines = %d, want 200", result.TotalLines)
+	}
+	want := map[string]int{"Alice": 120, "Bob": 80}
+	if got := authorLines(result); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("author lines = %v, want %v", got, want)
+	}
+	if result.Metrics == nil || result.Metrics.FilesProcessed != 80 {
+		t.Errorf("Metrics = %+v, want 80 files processed", result.Metrics)
+	}
+}
+
+// porcelainBlock returns one line of --line-porcelain output
+func porcelainBlock(commit, author, content string) string {
+	return commit + " 1 1 1\n" +
+		"author " + author + "\n" +
+		"author-mail <" + strings.ToLower(author) + "@example.com>\n" +
+		"author-time 1700000000\n" +
+		"author-tz +0000\n" +
+		"committer " + author + "\n" +
+		"committer-mail <" + strings.ToLower(author) + "@example.com>\n" +
+		"committer-time 1700000000\n" +
+		"committer-tz +0000\n" +
+		"summary Initial\n" +
+		"filename main.go\n" +
+		"\t" + content + "\n"
+}
+
+func TestParsePorcelainCRLF(t *testing.T) {
+	output := porcelainBlock("1111111111111111111111111111111111111111", "Alice", "package main") +
+		porcelainBlock("2222222222222222222222222222222222222222", "Bob Smith", "func main() {}")
+	output = strings.ReplaceAll(output, "\n", "\r\n")
+
+	parsed := parsePorcelain([]byte(output), CreditAuthor, TimeAuthor)
+	if len(parsed) != 2 {
+		t.Fatalf("parsed %d lines, want 2", len(parsed))
+	}
+	for i, want := range []string{"Alice", "Bob Smith"} {
+		if parsed[i].Author != want {
+			t.Errorf("line %d: author %q, want %q", i+1, parsed[i].Author, want)
+		}
+		if wantMail := strings.ToLower(want) + "@example.com"; parsed[i].Email != wantMail {
+			t.Errorf("line %d: email %q, want %q", i+1, parsed[i].Email, wantMail)
+		}
+		if parsed[i].Time != 1700000000 {
+			t.Errorf("line %d: time %d, want 1700000000", i+1, parsed[i].Time)
+		}
+	}
+}
+
+func TestParsePorcelainHeaderLikeContent(t *testing.T) {
+	output := porcelainBlock("1111111111111111111111111111111111111111", "Alice", "author foo") +
+		porcelainBlock("1111111111111111111111111111111111111111", "Alice", "committer bar")
+
+	for _, line := range parsePorcelain([]byte(output), CreditAuthor, TimeAuthor) {
+		if line.Author != "Alice" {
+			t.Errorf("content %q: author %q, want Alice", line.Content, line.Author)
+		}
+	}
+
+	r := newTestRepo(t)
+	r.commit("Alice", map[string]string{"notes.txt": "author foo\nauthor-mail <foo@example.com>\ncommitter foo\n"})
+	result := r.analyze(nil)
+	if got, want := authorLines(result), map[string]int{"Alice": 3}; fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("author lines = %v, want %v", got, want)
+	}
+}
+
+func TestUnicodeAuthorNames(t *testing.T) {
+	r := newTestRepo(t)
+	r.commit("Jürgen Müller 🚀", map[string]string{"a.txt": lines("a", 2)})
+	r.commit("José Ñúñez", map[string]string{"b.txt": lines("b", 1)})
+	r.commit("山田 太郎", map[string]string{"c.txt": lines("c", 1)})
+
+	result := r.analyze(nil)
+	want := map[string]int{"Jürgen Müller 🚀": 2, "José Ñúñez": 1, "山田 太郎": 1}
+	if got := authorLines(result); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("author lines = %v, want %v", got, want)
+	}
+}
+
+func TestEmptyAuthorName(t *testing.T) {
+	r := newTestRepo(t)
+	r.commit("Alice", map[string]string{"a.txt": lines("a", 2)})
+
+	// git refuses to record an empty name, but imported histories have
+	// them, so the commit is written directly
+	r.write("b.txt", lines("b", 3))
+	r.git("add", "b.txt")
+	tree := strings.TrimSpace(r.git("write-tree"))
+	parent := strings.TrimSpace(r.git("rev-parse", "HEAD"))
+	object := fmt.Sprintf("tree %s\nparent %s\n"+
+		"author  <import@example.com> 1700000000 +0000\n"+
+		"committer  <import@example.com> 1700000000 +0000\n\nImport\n", tree, parent)
+	cmd := exec.Command("git", "hash-object", "-t", "commit", "-w", "--stdin")
+	cmd.Dir = r.dir
+	cmd.Stdin = strings.NewReader(object)
+	hash, err := cmd.Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.git("update-ref", "HEAD", strings.TrimSpace(string(hash)))
+
+	tests := []struct {
+		name      string
+		configure func(*Config)
+		want      map[string]int
+	}{
+		{"default label", nil, map[string]int{"Alice": 2, "Unknown": 3}},
+		{"custom label", func(c *Config) { c.UnknownLabel = "(imported)" }, map[string]int{"Alice": 2, "(imported)": 3}},
+		{"excluded", func(c *Config) { c.ExcludeUnknown = true }, map[string]int{"Alice": 2}},
+	}
+	for _, tt := range tests {
+		if got := authorLines(r.analyze(tt.configure)); fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("%s: author lines = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestPathRegexFilters(t *testing.T) {
+	tests := []struct {
+		name    string
+		include []string
+		exclude []string
+		path    string
+		want    bool // excluded
+	}{
+		{"anchored include matches", []string{"^src/"}, nil, "src/main.go", false},
+		{"anchored include is anchored", []string{"^src/"}, nil, "vendor/src/x.go", true},
+		{"unanchored include matches anywhere", []string{"src/"}, nil, "vendor/src/x.go", false},
+		{"include alternation first", []string{"^(src|lib)/"}, nil, "src/a.go", false},
+		{"include alternation second", []string{"^(src|lib)/"}, nil, "lib/b.go", false},
+		{"include alternation neither", []string{"^(src|lib)/"}, nil, "docs/c.md", true},
+		{"ungrouped alternation anchors one side", []string{"^src/|lib/"}, nil, "third_party/lib/d.go", false},
+		{"exclude suffix anchor", nil, []string{`_test\.go$`}, "src/a_test.go", true},
+		{"exclude suffix anchor spares others", nil, []string{`_test\.go$`}, "src/a_test.gopher/x.txt", false},
+		{"exclude directory at any depth", nil, []string{"(^|/)generated/"}, "api/generated/x.go", true},
+		{"exclude directory at the top", nil, []string{"(^|/)generated/"}, "generated/x.go", true},
+		{"exclude directory needs a boundary", nil, []string{"(^|/)generated/"}, "api/pregenerated/x.go", false},
+		{"exclude wins over include", []string{"^src/"}, []string{`_test\.go$`}, "src/a_test.go", true},
+		{"several includes", []string{"^src/", "^cmd/"}, nil, "cmd/main.go", false},
+	}
+	for _, tt := range tests {
+		config := defaultConfig(t.TempDir())
+		config.IncludePathRegex = tt.include
+		config.ExcludePathRegex = tt.exclude
+		ga := NewGitAnalyzer(config)
+		if got := ga.shouldExcludeFile(filepath.FromSlash(tt.path)); got != tt.want {
+			t.Errorf("%s: excluded(%s) = %v, want %v", tt.name, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestUsageErrorExitCode(t *testing.T) {
+	inner := errors.New("bad delimiter")
+	err := usageErrorf("invalid --csv-delimiter: %w", inner)
+	if code := exitCode(err); code != ExitCodeUsage {
+		t.Errorf("exitCode = %d, want %d", code, ExitCodeUsage)
+	}
+	if !errors.Is(err, inner) {
+		t.Error("usageErrorf doesn't wrap its %w argument")
+	}
+	if code := exitCode(fmt.Errorf("analysis failed")); code != ExitCodeError {
+		t.Errorf("exitCode of a plain error = %d, want %d", code, ExitCodeError)
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	for value, want := range map[string]int64{
+		"512":    512,
+		"5MB":    5_000_000,
+		"5M":     5 << 20,
+		"5 MiB":  5 << 20,
+		"1.5kb":  1500,
+		"1GiB":   1 << 30,
+		"2g":     2 << 30,
+		" 10B ":  10,
+		"0.5KiB": 512,
+	} {
+		if got, err := parseSize(value); err != nil || got != want {
+			t.Errorf("parseSize(%q) = %d, %v, want %d", value, got, err, want)
+		}
+	}
+	for _, value := range []string{"", "MB", "-1KB", "5TB"} {
+		if _, err := parseSize(value); err == nil {
+			t.Errorf("parseSize(%q) succeeded", value)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import "slices"
+
+// JSONSchemaVersion is the version of the JSON output's layout, reported as
+// schema_version. It's bumped when fields are renamed or removed, or change
+// meaning; adding fields doesn't bump it.
+const JSONSchemaVersion = 1
+
+// Metrics are flat scalar metrics summarizing the analysis, for dashboards
+// and monitoring that scrape JSON output without walking the authors array
+type Metrics struct {
+	TotalLines       int     `json:"total_lines"`
+	TotalFiles       int     `json:"total_files"`
+	FilesProcessed   int     `json:"files_processed"`
+	UniqueAuthors    int     `json:"unique_authors"`
+	BusFactor        int     `json:"bus_factor"`     // fewest authors owning at least half of the authors' lines
+	Gini             float64 `json:"gini"`           // inequality of the authors' lines, 0 when equal, near 1 when one author owns everything
+	TopAuthorPct     float64 `json:"top_author_pct"` // the largest author's percentage
+	ProcessingTimeMs int64   `json:"processing_time_ms"`
+}
+
+// ownershipMetrics measures how concentrated ownership is among every
+// author, including those cut by --limit. Lines are weighted with
+// --weighted, like the percentages. The totals are filled in by setTotals
+// once the result is complete.
+func (ga *GitAnalyzer) ownershipMetrics(authors []AuthorStats) *Metrics {
+	metrics := &Metrics{UniqueAuthors: len(authors)}
+	if len(authors) == 0 {
+		return metrics
+	}
+
+	lines := make([]float64, len(authors))
+	total := 0.0
+	for i, author := range authors {
+		lines[i] = float64(author.LineCount)
+		if ga.config.Weighted {
+			lines[i] = author.WeightedLines
+		}
+		total += lines[i]
+		metrics.TopAuthorPct = max(metrics.TopAuthorPct, author.Percentage)
+	}
+	slices.Sort(lines)
+
+	owned := 0.0
+	for i := len(lines) - 1; i >= 0 && owned*2 < total; i-- {
+		owned += lines[i]
+		metrics.BusFactor++
+	}
+	metrics.Gini = gini(lines, total)
+
+	return metrics
+}
+
+// gini computes the Gini coefficient of ascending values summing to total:
+// G = 2·Σ(i·x_i) / (n·Σx) − (n+1)/n with i counted from 1
+func gini(sorted []float64, total float64) float64 {
+	if total == 0 {
+		return 0
+	}
+	weighted := 0.0
+	for i, x := range sorted {
+		weighted += float64(i+1) * x
+	}
+	n := float64(len(sorted))
+	return 2*weighted/(n*total) - (n+1)/n
+}
+
+// setTotals copies the result's totals into the metrics
+func (m *Metrics) setTotals(result *AnalysisResult) {
+	m.TotalLines = result.TotalLines
+	m.TotalFiles = result.TotalFiles
+	m.FilesProcessed = result.FilesProcessed
+	m.ProcessingTimeMs = result.ProcessingTime.Milliseconds()
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"math"
+	"strconv"
+)
+
+// MoveCredit selects how lines moved or copied between files are credited
+type MoveCredit string
+
+const (
+	MovesOriginal     MoveCredit = "original"     // to the line's first author, in every copy
+	MovesPhysical     MoveCredit = "physical"     // to the author of the commit that copied the line
+	MovesProportional MoveCredit = "proportional" // to the line's first author, split between its copies
+)
+
+// blameArgs returns the git blame arguments shared by every blame call. Moves
+// within a file are always followed; copies from other files are followed
+// unless lines are credited physically.
+func (ga *GitAnalyzer) blameArgs() []string {
+	args := []string{"blame", "-M"}
+	if ga.config.CreditMoves != MovesPhysical {
+		args = append(args, "-C")
+	}
+	args = append(args, "-w", "--line-porcelain")
+	return append(args, ga.ignoreRevsArgs()...)
+}
+
+// originKey identifies the line a blamed line was copied from: the same
+// commit, path and line number in every surviving copy
+func originKey(line blameLine) string {
+	return line.Commit + " " + strconv.Itoa(line.OrigLine) + " " + line.OrigPath
+}
+
+// copyTally splits the credit for copied lines between their surviving
+// copies with --credit-moves proportional, so a block copied to a second
+// file and kept in both counts as one line in total
+type copyTally struct {
+	copies  map[string]int      // surviving copies of each origin line
+	origins map[string][]string // origin of each tallied author's lines
+}
+
+func newCopyTally() *copyTally {
+	return &copyTally{
+		copies:  make(map[string]int),
+		origins: make(map[string][]string),
+	}
+}
+
+// add counts a surviving copy of an origin line, crediting it to the author
+// unless author is ""
+func (t *copyTally) add(author, origin string) {
+	t.copies[origin]++
+	if author != "" {
+		t.origins[author] = append(t.origins[author], origin)
+	}
+}
+
+// credits returns each tallied author's share of lines, each copy of a line
+// being worth 1/copies, and the total, which is the number of distinct lines
+func (t *copyTally) credits() (map[string]float64, float64) {
+	credits := make(map[string]float64, len(t.origins))
+	for author, origins := range t.origins {
+		for _, origin := range origins {
+			credits[author] += 1 / float64(t.copies[origin])
+		}
+	}
+	return credits, float64(len(t.copies))
+}
+
+// roundCredit converts a proportional credit to a line count
+func roundCredit(credit float64) int {
+	return int(math.Round(credit))
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+)
+
+// OrphanedFile is a file no author clearly owns: even its top author owns
+// less than --orphan-threshold of its lines
+type OrphanedFile struct {
+	Path      string  `json:"path"`
+	LineCount int     `json:"line_count"`
+	Authors   int     `json:"authors"`
+	TopAuthor string  `json:"top_author"`
+	TopShare  float64 `json:"top_author_percentage"`
+}
+
+// orphanedFile returns the file's ownership when its top author owns less
+// than --orphan-threshold of its blamed lines, or nil when the file has a
+// clear owner. Lines are counted per tallied author, so merged identities
+// count as one owner.
+func (ga *GitAnalyzer) orphanedFile(result BlameResult) *OrphanedFile {
+	counts := make(map[string]int)
+	lines := 0
+	for _, blamed := range result.Authors {
+		if blamed != "" {
+			counts[ga.tallyName(blamed)]++
+			lines++
+		}
+	}
+	if lines == 0 {
+		return nil
+	}
+
+	orphan := &OrphanedFile{LineCount: lines, Authors: len(counts)}
+	top := 0
+	for author, count := range counts {
+		// Ties go to the alphabetically first author so runs agree
+		if count > top || count == top && author < orphan.TopAuthor {
+			top = count
+			orphan.TopAuthor = author
+		}
+	}
+	orphan.TopShare = float64(top) / float64(lines) * 100
+	if orphan.TopShare >= ga.config.OrphanThreshold {
+		return nil
+	}
+
+	orphan.Path, _ = filepath.Rel(ga.config.Directory, result.FilePath)
+	return orphan
+}
+
+// sortOrphans orders orphaned files from the most fragmented, breaking ties
+// by size so the larger files come first
+func sortOrphans(orphans []OrphanedFile) {
+	sort.Slice(orphans, func(i, j int) bool {
+		if orphans[i].TopShare != orphans[j].TopShare {
+			return orphans[i].TopShare < orphans[j].TopShare
+		}
+		if orphans[i].LineCount != orphans[j].LineCount {
+			return orphans[i].LineCount > orphans[j].LineCount
+		}
+		return orphans[i].Path < orphans[j].Path
+	})
+}
+
+// displayOrphans displays the files whose top author owns less than
+// --orphan-threshold of their lines
+func (ga *GitAnalyzer) displayOrphans(w io.Writer, result *AnalysisResult) {
+	if ga.config.OrphanThreshold <= 0 {
+		return
+	}
+
+	if !ga.config.Quiet {
+		fmt.Fprintf(w, "\n%s\n", ga.styleHeader("Orphaned Files"))
+		if len(result.OrphanedFiles) == 0 {
+			fmt.Fprintf(w, "Every file has an author owning at least %g%% of it\n", ga.config.OrphanThreshold)
+			return
+		}
+	}
+
+	table := ga.newTable(w)
+	table.Header([]string{"Lines", "Authors", "Top Share", "Top Author", "File"})
+	for _, orphan := range result.OrphanedFiles {
+		table.Append([]string{
+			ga.formatNumber(orphan.LineCount),
+			ga.formatNumber(orphan.Authors),
+			ga.formatPercent(orphan.TopShare, 1),
+			orphan.TopAuthor,
+			orphan.Path,
+		})
+	}
+	table.Render()
+}
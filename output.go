@@ -0,0 +1,153 @@
+package main
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// OutputFormatter renders an analysis result
+type OutputFormatter interface {
+	Format(w io.Writer, result *AnalysisResult) error
+}
+
+// OutputFormatterFunc adapts a function to the OutputFormatter interface
+type OutputFormatterFunc func(w io.Writer, result *AnalysisResult) error
+
+// Format calls f(w, result)
+func (f OutputFormatterFunc) Format(w io.Writer, result *AnalysisResult) error {
+	return f(w, result)
+}
+
+// formatters maps each output format name to a constructor for its
+// formatter. Formatters are built per analyzer so they can honor its config.
+var formatters = map[OutputFormat]func(ga *GitAnalyzer) OutputFormatter{
+	FormatTable:  func(ga *GitAnalyzer) OutputFormatter { return OutputFormatterFunc(ga.outputTable) },
+	FormatJSON:   func(ga *GitAnalyzer) OutputFormatter { return OutputFormatterFunc(ga.outputJSON) },
+	FormatCSV:    func(ga *GitAnalyzer) OutputFormatter { return OutputFormatterFunc(ga.outputCSV) },
+	FormatPlain:  func(ga *GitAnalyzer) OutputFormatter { return OutputFormatterFunc(ga.outputPlain) },
+	FormatDot:    func(ga *GitAnalyzer) OutputFormatter { return OutputFormatterFunc(ga.outputDot) },
+	FormatInflux: func(ga *GitAnalyzer) OutputFormatter { return OutputFormatterFunc(ga.outputInflux) },
+	FormatJSONL:  func(ga *GitAnalyzer) OutputFormatter { return OutputFormatterFunc(ga.outputJSONL) },
+}
+
+// RegisterFormatter makes an output format available under the given name,
+// replacing any formatter already registered under it
+func RegisterFormatter(name OutputFormat, newFormatter func(ga *GitAnalyzer) OutputFormatter) {
+	formatters[name] = newFormatter
+}
+
+// formatterNames returns the registered output format names, sorted
+func formatterNames() []string {
+	names := make([]string, 0, len(formatters))
+	for name := range formatters {
+		names = append(names, string(name))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// formatter looks up the formatter for the configured output format
+func (ga *GitAnalyzer) formatter() (OutputFormatter, error) {
+	newFormatter, ok := formatters[ga.config.OutputFormat]
+	if !ok {
+		return nil, fmt.Errorf("unknown output format %q", ga.config.OutputFormat)
+	}
+	return newFormatter(ga), nil
+}
+
+// formatsByExtension infers the --also-write format from the file name
+var formatsByExtension = map[string]OutputFormat{
+	".json":   FormatJSON,
+	".csv":    FormatCSV,
+	".txt":    FormatPlain,
+	".tsv":    FormatPlain,
+	".dot":    FormatDot,
+	".gv":     FormatDot,
+	".lp":     FormatInflux,
+	".jsonl":  FormatJSONL,
+	".ndjson": FormatJSONL,
+}
+
+// gzipSuffix marks an --also-write file to be gzip-compressed
+const gzipSuffix = ".gz"
+
+// isGzipPath reports whether a file is written gzip-compressed
+func isGzipPath(path string) bool {
+	return strings.HasSuffix(strings.ToLower(path), gzipSuffix)
+}
+
+// formatForPath returns the output format for a file, from its extension,
+// looking past a .gz suffix
+func formatForPath(path string) (OutputFormat, bool) {
+	if isGzipPath(path) {
+		path = path[:len(path)-len(gzipSuffix)]
+	}
+	format, ok := formatsByExtension[strings.ToLower(filepath.Ext(path))]
+	return format, ok
+}
+
+// writes reports whether the results are rendered in the given format,
+// either to stdout or to the --also-write file
+func (ga *GitAnalyzer) writes(format OutputFormat) bool {
+	return ga.config.OutputFormat == format ||
+		(ga.config.AlsoWrite != "" && ga.config.AlsoWriteFormat == format)
+}
+
+// alsoWrite renders the same results a second time, in the --also-write
+// format, to the --also-write file, gzip-compressed when its name ends in .gz
+func (ga *GitAnalyzer) alsoWrite(result *AnalysisResult) error {
+	if ga.config.AlsoWrite == "" {
+		return nil
+	}
+
+	alt := *ga
+	alt.config.OutputFormat = ga.config.AlsoWriteFormat
+	formatter, err := alt.formatter()
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Create(ga.config.AlsoWrite)
+	if err != nil {
+		return fmt.Errorf("failed to create --also-write file: %w", err)
+	}
+	var w io.Writer = file
+	var compressed *gzip.Writer
+	if isGzipPath(ga.config.AlsoWrite) {
+		compressed = gzip.NewWriter(file)
+		w = compressed
+	}
+	if err := formatter.Format(w, result); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write %s: %w", ga.config.AlsoWrite, err)
+	}
+	if compressed != nil {
+		// Close flushes the compressed data and writes the gzip footer
+		if err := compressed.Close(); err != nil {
+			file.Close()
+			return fmt.Errorf("failed to write %s: %w", ga.config.AlsoWrite, err)
+		}
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", ga.config.AlsoWrite, err)
+	}
+
+	if !ga.config.Quiet {
+		ga.logInfo("Wrote %s output to %s", alt.config.OutputFormat, ga.config.AlsoWrite)
+	}
+	return nil
+}
+
+// displayResults writes the analysis results to stdout in the configured format
+func (ga *GitAnalyzer) displayResults(result *AnalysisResult) error {
+	formatter, err := ga.formatter()
+	if err != nil {
+		return err
+	}
+	return formatter.Format(os.Stdout, result)
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"math"
+	"strconv"
+)
+
+// defaultPrecision leaves each format's percentages at their usual
+// precision: one decimal in tables, two in CSV and plain output, and full
+// precision in JSON and time-series output
+const defaultPrecision = -1
+
+// maxPrecision is the most decimal places --precision accepts
+const maxPrecision = 10
+
+// decimals returns the decimal places of percentages in a format whose
+// default is def
+func (ga *GitAnalyzer) decimals(def int) int {
+	if ga.config.Precision >= 0 {
+		return ga.config.Precision
+	}
+	return def
+}
+
+// csvPercent formats a percentage for CSV output, without a percent sign or
+// locale-specific separators
+func (ga *GitAnalyzer) csvPercent(pct float64) string {
+	return strconv.FormatFloat(pct, 'f', ga.decimals(2), 64)
+}
+
+// roundPercentages rounds every percentage in the result to --precision
+// decimal places, so machine-readable output matches the tables
+func (ga *GitAnalyzer) roundPercentages(result *AnalysisResult) {
+	if ga.config.Precision < 0 {
+		return
+	}
+	scale := math.Pow(10, float64(ga.config.Precision))
+	round := func(pct *float64) {
+		*pct = math.Round(*pct*scale) / scale
+	}
+
+	for i := range result.Authors {
+		round(&result.Authors[i].Percentage)
+		round(&result.Authors[i].Percentile)
+	}
+	if result.Others != nil {
+		round(&result.Others.Percentage)
+	}
+	for i := range result.Teams {
+		round(&result.Teams[i].Percentage)
+	}
+	for i := range result.UserContributions {
+		round(&result.UserContributions[i].Share)
+		round(&result.UserContributions[i].Ownership)
+	}
+	for i := range result.Tiers {
+		round(&result.Tiers[i].Percentage)
+	}
+	if result.Metrics != nil {
+		round(&result.Metrics.TopAuthorPct)
+	}
+	if result.FilterTotals != nil {
+		round(&result.FilterTotals.Percentage)
+	}
+	for i := range result.Directories {
+		round(&result.Directories[i].Percentage)
+		for j := range result.Directories[i].TopAuthors {
+			round(&result.Directories[i].TopAuthors[j].Percentage)
+		}
+	}
+	for i := range result.OrphanedFiles {
+		round(&result.OrphanedFiles[i].TopShare)
+	}
+	for i := range result.DiffFiles {
+		for j := range result.DiffFiles[i].Owners {
+			round(&result.DiffFiles[i].Owners[j].Percentage)
+		}
+	}
+	if h := result.Highlights; h != nil {
+		if h.MostActive != nil {
+			round(&h.MostActive.Percentage)
+		}
+		if h.TopDirectory != nil {
+			round(&h.TopDirectory.OwnerShare)
+		}
+		if h.DominantLanguage != nil {
+			round(&h.DominantLanguage.Percentage)
+		}
+	}
+}
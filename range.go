@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// parseLineRange parses a 1-based inclusive line range like "100-250"
+func parseLineRange(value string) (int, int, error) {
+	startStr, endStr, ok := strings.Cut(value, "-")
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid line range %q (expected START-END, e.g. 100-250)", value)
+	}
+
+	start, err := strconv.Atoi(strings.TrimSpace(startStr))
+	if err != nil || start < 1 {
+		return 0, 0, fmt.Errorf("invalid start line in %q", value)
+	}
+	end, err := strconv.Atoi(strings.TrimSpace(endStr))
+	if err != nil || end < start {
+		return 0, 0, fmt.Errorf("invalid end line in %q", value)
+	}
+
+	return start, end, nil
+}
+
+// analyzeFile blames a single file, optionally restricted to a line range,
+// instead of walking the directory
+func (ga *GitAnalyzer) analyzeFile(ctx context.Context) (*AnalysisResult, error) {
+	startTime := time.Now()
+
+	path := ga.config.File
+	if filepath.IsAbs(path) {
+		rel, err := filepath.Rel(ga.config.Directory, path)
+		if err != nil {
+			return nil, err
+		}
+		path = rel
+	}
+	path = filepath.ToSlash(path)
+
+	args := ga.blameArgs()
+	if ga.config.DateSince != "" {
+		args = append(args, "--since="+ga.config.DateSince)
+	}
+	if ga.config.DateUntil != "" {
+		args = append(args, "--until="+ga.config.DateUntil)
+	}
+	if ga.config.LineRange != "" {
+		start, end, err := parseLineRange(ga.config.LineRange)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, "-L", fmt.Sprintf("%d,%d", start, end))
+	}
+	args = append(args, "--", path)
+
+	if !ga.config.Quiet {
+		if ga.config.LineRange != "" {
+			ga.logInfo("Analyzing lines %s of %s", ga.config.LineRange, path)
+		} else {
+			ga.logInfo("Analyzing %s", path)
+		}
+	}
+
+	output, err := ga.gitCommand(ctx, args...).Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+			return nil, fmt.Errorf("git blame %s: %s", path, strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return nil, fmt.Errorf("git blame %s: %w", path, err)
+	}
+
+	parsed := ga.parseBlame(output, path)
+	blamed, excluded := parsed.Authors, parsed.Excluded
+
+	authors := make([]AuthorStats, 0)
+	for _, owner := range ga.countOwners(blamed) {
+		if owner.LineCount >= ga.config.MinLines {
+			authors = append(authors, owner)
+		}
+	}
+
+	assignPercentiles(authors)
+	ga.assignRanks(authors)
+	ga.sortAuthors(authors)
+	metrics := ga.ownershipMetrics(authors)
+
+	if ga.config.MaxResults > 0 && len(authors) > ga.config.MaxResults {
+		authors = authors[:ga.config.MaxResults]
+	}
+
+	var history []HistoryStats
+	var formerPaths []string
+	if ga.config.FollowRenames {
+		if history, formerPaths, err = ga.fileHistory(ctx, path); err != nil {
+			return nil, err
+		}
+	}
+
+	return &AnalysisResult{
+		Authors:         authors,
+		TotalLines:      len(blamed),
+		FilesProcessed:  1,
+		TotalFiles:      1,
+		ProcessingTime:  time.Since(startTime),
+		Repository:      ga.config.Directory,
+		GeneratedAt:     time.Now(),
+		UnfilteredLines: ga.untrimmedTotal(len(blamed) + excluded),
+		File:            path,
+		LineRange:       ga.config.LineRange,
+		History:         history,
+		FormerPaths:     formerPaths,
+		Metrics:         metrics,
+	}, nil
+}
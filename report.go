@@ -0,0 +1,262 @@
+package main
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// reportPage is the report bundle's index.html. It's self-contained, with
+// no scripts or external assets, so it opens anywhere once unzipped.
+var reportPage = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>gala report: {{.Name}}</title>
+<style>
+body { font-family: system-ui, sans-serif; margin: 2rem auto; max-width: 60rem; padding: 0 1rem; color: #222; }
+h1 { margin-bottom: 0.25rem; }
+p.meta { color: #666; margin-top: 0; }
+table { border-collapse: collapse; width: 100%; margin-bottom: 2rem; }
+th, td { border-bottom: 1px solid #ddd; padding: 0.4rem 0.6rem; text-align: left; }
+th { background: #f4f4f4; }
+td.num { text-align: right; font-variant-numeric: tabular-nums; }
+.bar { background: #4c8bf5; height: 0.6rem; border-radius: 0.2rem; }
+</style>
+</head>
+<body>
+<h1>{{.Name}}</h1>
+<p class="meta">Generated {{.Generated}} by gala {{.Version}} from {{.Repository}}. The full results are in data.json.</p>
+<h2>Summary</h2>
+<table>
+{{- range .Summary}}
+<tr><th>{{index . 0}}</th><td>{{index . 1}}</td></tr>
+{{- end}}
+</table>
+<h2>Authors</h2>
+<table>
+<tr><th>Rank</th><th>Author</th><th>Lines</th><th>Files</th><th>Percentage</th><th></th></tr>
+{{- range .Authors}}
+<tr><td class="num">{{.Rank}}</td><td>{{.Name}}</td><td class="num">{{.Lines}}</td><td class="num">{{.Files}}</td><td class="num">{{.Percentage}}</td><td style="width: 30%"><div class="bar" style="width: {{.Width}}%"></div></td></tr>
+{{- end}}
+</table>
+</body>
+</html>
+`))
+
+// reportAuthor is an author row of the HTML report, formatted for display
+type reportAuthor struct {
+	Rank       int
+	Name       string
+	Lines      string
+	Files      string
+	Percentage string
+	Width      string // of the bar, a percentage of the largest author's
+}
+
+// outputReportHTML renders the results as the report bundle's index.html
+func (ga *GitAnalyzer) outputReportHTML(w io.Writer, result *AnalysisResult) error {
+	summary := [][]string{
+		{"Total lines analyzed", ga.formatNumber(result.TotalLines)},
+		{"Unique authors", ga.formatNumber(len(result.Authors))},
+		{"Files processed", ga.formatNumber(result.FilesProcessed)},
+		{"Processing time", result.ProcessingTime.Round(time.Millisecond).String()},
+	}
+	if m := result.Metrics; m != nil {
+		summary = append(summary,
+			[]string{"Bus factor", ga.formatNumber(m.BusFactor)},
+			[]string{"Gini coefficient", ga.printer.Sprintf("%.2f", m.Gini)})
+	}
+	if stats := result.RepoStats; stats != nil {
+		summary = append(summary,
+			[]string{"First commit", ga.displayDate(stats.FirstCommit)},
+			[]string{"Last commit", ga.displayDate(stats.LastCommit)},
+			[]string{"Commits", ga.formatNumber(stats.CommitCount)})
+	}
+	if result.Highlights != nil {
+		summary = append(summary, ga.highlightRows(result.Highlights)...)
+	}
+
+	authors := make([]reportAuthor, len(result.Authors))
+	largest := 0.0
+	for _, author := range result.Authors {
+		largest = max(largest, author.Percentage)
+	}
+	for i, author := range result.Authors {
+		authors[i] = reportAuthor{
+			Rank:       author.Rank,
+			Name:       author.Name,
+			Lines:      ga.formatNumber(author.LineCount),
+			Files:      ga.formatNumber(author.FileCount),
+			Percentage: ga.formatPercent(author.Percentage, 1),
+		}
+		if largest > 0 {
+			authors[i].Width = fmt.Sprintf("%.1f", author.Percentage/largest*100)
+		}
+	}
+
+	return reportPage.Execute(w, struct {
+		Name       string
+		Repository string
+		Generated  string
+		Version    string
+		Summary    [][]string
+		Authors    []reportAuthor
+	}{
+		Name:       filepath.Base(result.Repository),
+		Repository: result.Repository,
+		Generated:  result.GeneratedAt.UTC().Format("2006-01-02 15:04 MST"),
+		Version:    Version,
+		Summary:    summary,
+		Authors:    authors,
+	})
+}
+
+// writeReport writes the report bundle: index.html and the JSON results as
+// data.json, both rendered from the same analysis. An existing file is only
+// replaced with force. The bundle is written to a temporary file next to
+// path and moved into place once complete, so a failed write leaves any
+// existing file untouched and no partial bundle behind.
+func (ga *GitAnalyzer) writeReport(path string, result *AnalysisResult, force bool) (err error) {
+	file, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to create report: %w", err)
+	}
+	tmpPath := file.Name()
+	defer func() {
+		file.Close()
+		os.Remove(tmpPath)
+	}()
+	if err := file.Chmod(0o644); err != nil {
+		return fmt.Errorf("failed to create report: %w", err)
+	}
+
+	bundle := zip.NewWriter(file)
+	entries := []struct {
+		name   string
+		render func(io.Writer, *AnalysisResult) error
+	}{
+		{"index.html", ga.outputReportHTML},
+		{"data.json", ga.outputJSON},
+	}
+	for _, entry := range entries {
+		w, err := bundle.CreateHeader(&zip.FileHeader{
+			Name:     entry.name,
+			Method:   zip.Deflate,
+			Modified: result.GeneratedAt,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to write %s: %w", path, err)
+		}
+		if err := entry.render(w, result); err != nil {
+			return fmt.Errorf("failed to write %s of %s: %w", entry.name, path, err)
+		}
+	}
+	// Close writes the zip's central directory
+	if err := bundle.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+
+	if force {
+		err = os.Rename(tmpPath, path)
+	} else {
+		// Unlike a rename, a link fails when path has appeared meanwhile
+		err = os.Link(tmpPath, path)
+		if err != nil && !errors.Is(err, os.ErrExist) {
+			// The filesystem may not support hard links
+			err = claimAndRename(tmpPath, path)
+		}
+	}
+	if errors.Is(err, os.ErrExist) {
+		return fmt.Errorf("%s already exists; pass --force to overwrite it", path)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// claimAndRename moves tmpPath to path unless path exists, without hard
+// links: path is claimed by creating it exclusively, then replaced
+func claimAndRename(tmpPath, path string) error {
+	claim, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	claim.Close()
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(path)
+		return err
+	}
+	return nil
+}
+
+// newReportCmd creates the command bundling the results into a shareable
+// zip file
+func newReportCmd() *cobra.Command {
+	var out string
+	var force bool
+
+	reportCmd := &cobra.Command{
+		Use:   "report [repo]",
+		Short: "Write a zip file with an HTML report and the JSON results",
+		Long: `Analyze a repository and write a self-contained report bundle: a zip
+file holding index.html, a readable report with the summary, highlights,
+repository history and authors, and data.json, the full results as with
+--output json. Both come from a single analysis.
+
+An existing file is not overwritten unless --force is given.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			repo := "."
+			if len(args) > 0 {
+				repo = args[0]
+			}
+			absRepo, err := filepath.Abs(repo)
+			if err != nil {
+				return fmt.Errorf("invalid repository path: %w", err)
+			}
+			absOut, err := filepath.Abs(out)
+			if err != nil {
+				return fmt.Errorf("invalid --out path: %w", err)
+			}
+			if !force {
+				// Fail before the analysis rather than after it
+				if _, err := os.Stat(absOut); err == nil {
+					return fmt.Errorf("%s already exists; pass --force to overwrite it", absOut)
+				}
+			}
+
+			config := defaultConfig(absRepo)
+			config.RichSummary = true
+			config.RepoStats = true
+
+			analyzer := NewGitAnalyzer(config)
+			result, err := analyzer.Analyze(cmd.Context())
+			if err != nil {
+				return err
+			}
+			if err := analyzer.writeReport(absOut, result, force); err != nil {
+				return err
+			}
+
+			fmt.Fprintf(cmd.ErrOrStderr(), "Wrote report to %s\n", absOut)
+			return nil
+		},
+	}
+
+	reportCmd.Flags().StringVar(&out, "out", "report.zip", "Path of the zip file to write")
+	reportCmd.Flags().BoolVar(&force, "force", false, "Overwrite the zip file if it already exists")
+
+	return reportCmd
+}
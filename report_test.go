@@ -0,0 +1,86 @@
+package main
+
+import (
+	"archive/zip"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWriteReportReplacesAtomically(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "report.zip")
+	if err := os.WriteFile(path, []byte("old"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	ga := NewGitAnalyzer(defaultConfig(dir))
+	result := &AnalysisResult{
+		Repository:  dir,
+		GeneratedAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		Authors:     []AuthorStats{{Name: "Alice", LineCount: 3, FileCount: 1, Percentage: 100, Rank: 1}},
+		TotalLines:  3,
+	}
+
+	if err := ga.writeReport(path, result, false); err == nil {
+		t.Error("writeReport without force replaced an existing file")
+	}
+	if data, _ := os.ReadFile(path); string(data) != "old" {
+		t.Errorf("existing file changed to %q without force", data)
+	}
+
+	if err := ga.writeReport(path, result, true); err != nil {
+		t.Fatal(err)
+	}
+	bundle, err := zip.OpenReader(path)
+	if err != nil {
+		t.Fatalf("report isn't a zip file: %v", err)
+	}
+	defer bundle.Close()
+	var names []string
+	for _, file := range bundle.File {
+		names = append(names, file.Name)
+	}
+	if len(names) != 2 || names[0] != "index.html" || names[1] != "data.json" {
+		t.Errorf("bundle holds %v, want index.html and data.json", names)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("directory holds %d files, want only the report", len(entries))
+	}
+	if info, err := os.Stat(path); err == nil && info.Mode().Perm() != 0o644 {
+		t.Errorf("report mode = %v, want 0644", info.Mode().Perm())
+	}
+}
+
+func TestClaimAndRename(t *testing.T) {
+	dir := t.TempDir()
+	tmp := filepath.Join(dir, ".report.zip.tmp")
+	path := filepath.Join(dir, "report.zip")
+	if err := os.WriteFile(tmp, []byte("new"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := claimAndRename(tmp, path); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "new" {
+		t.Errorf("report = %q, want new", data)
+	}
+
+	// An existing file is kept
+	if err := os.WriteFile(tmp, []byte("newer"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := claimAndRename(tmp, path); !errors.Is(err, os.ErrExist) {
+		t.Errorf("claimAndRename over an existing file = %v, want ErrExist", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "new" {
+		t.Errorf("existing report changed to %q", data)
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// week is the period --repo-stats averages commits over
+const week = 7 * 24 * time.Hour
+
+// RepoStats frames the results with the age and pace of the repository's
+// history, for --repo-stats
+type RepoStats struct {
+	FirstCommit    string  `json:"first_commit"`
+	LastCommit     string  `json:"last_commit"`
+	CommitCount    int     `json:"commit_count"`
+	CommitsPerWeek float64 `json:"commits_per_week"`
+}
+
+// repoStats summarizes the history of HEAD, or of every branch with
+// --all-branches, with a single git log call listing each commit's date,
+// dated like the lines (--time-basis). Histories shorter than a week count
+// as one week, so a young repository's pace isn't inflated.
+func (ga *GitAnalyzer) repoStats(ctx context.Context) (*RepoStats, error) {
+	format := "--format=%at"
+	if ga.timeBasis() == TimeCommitter {
+		format = "--format=%ct"
+	}
+	args := []string{"log", format, "HEAD"}
+	if ga.config.AllBranches {
+		args = []string{"log", format, "--all"}
+	}
+	output, err := ga.gitCommand(ctx, args...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("git log: %w", err)
+	}
+
+	var first, last int64
+	commits := 0
+	for field := range strings.FieldsSeq(string(output)) {
+		t, err := strconv.ParseInt(field, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("unexpected git log output %q", field)
+		}
+		if commits == 0 || t < first {
+			first = t
+		}
+		if commits == 0 || t > last {
+			last = t
+		}
+		commits++
+	}
+	if commits == 0 {
+		return nil, fmt.Errorf("no commits found")
+	}
+
+	weeks := max(float64(time.Duration(last-first)*time.Second)/float64(week), 1)
+	return &RepoStats{
+		FirstCommit:    formatCommitDate(first),
+		LastCommit:     formatCommitDate(last),
+		CommitCount:    commits,
+		CommitsPerWeek: float64(commits) / weeks,
+	}, nil
+}
+
+// displayRepoStats displays the --repo-stats header ahead of the results
+func (ga *GitAnalyzer) displayRepoStats(w io.Writer, result *AnalysisResult) {
+	stats := result.RepoStats
+	if stats == nil {
+		return
+	}
+
+	if !ga.config.Quiet {
+		fmt.Fprintf(w, "\n%s\n", ga.styleHeader("Repository"))
+	}
+	table := ga.newTable(w)
+	table.Header([]string{"Metric", "Value"})
+	table.Append([]string{"First commit", ga.displayDate(stats.FirstCommit)})
+	table.Append([]string{"Last commit", ga.displayDate(stats.LastCommit)})
+	table.Append([]string{"Commits", ga.formatNumber(stats.CommitCount)})
+	table.Append([]string{"Commits per week", ga.printer.Sprintf("%.2f", stats.CommitsPerWeek)})
+	table.Render()
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"math/rand/v2"
+	"time"
+)
+
+// sampleFiles picks --sample files at random, reproducibly for a given
+// --seed. The population is returned unchanged when it isn't larger than
+// the sample.
+func (ga *GitAnalyzer) sampleFiles(files []string) []string {
+	if ga.config.Sample <= 0 || ga.config.Sample >= len(files) {
+		return files
+	}
+
+	seed := ga.config.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	ga.logDebug("Sampling %d of %d files with seed %d", ga.config.Sample, len(files), seed)
+
+	// A partial Fisher-Yates shuffle over a copy draws the sample uniformly
+	// without replacement
+	rng := rand.New(rand.NewPCG(uint64(seed), 0))
+	shuffled := make([]string, len(files))
+	copy(shuffled, files)
+	for i := 0; i < ga.config.Sample; i++ {
+		j := i + rng.IntN(len(shuffled)-i)
+		shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
+	}
+
+	return shuffled[:ga.config.Sample]
+}
+
+// extrapolate marks a result as an estimate from a sample of the given
+// population and scales its line total to the whole population
+func (result *AnalysisResult) extrapolate(population int) {
+	result.Sampled = true
+	result.SampleSize = result.TotalFiles
+	result.TotalFiles = population
+	if result.FilesProcessed > 0 {
+		result.EstimatedTotalLines = int(float64(result.TotalLines) * float64(population) / float64(result.FilesProcessed))
+	}
+}
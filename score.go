@@ -0,0 +1,76 @@
+package main
+
+import (
+	"errors"
+	"math"
+	"time"
+)
+
+// ScoreWeights configures the contribution score. Each component is
+// normalized to 0..1 before weighting; see assignScores for the formula.
+type ScoreWeights struct {
+	Lines        float64 `mapstructure:"lines"`
+	Files        float64 `mapstructure:"files"`
+	Recency      float64 `mapstructure:"recency"`
+	HalfLifeDays float64 `mapstructure:"half-life-days"`
+}
+
+// defaultScoreWeights favor volume of code, then breadth, then recency
+var defaultScoreWeights = ScoreWeights{
+	Lines:        0.5,
+	Files:        0.3,
+	Recency:      0.2,
+	HalfLifeDays: 180,
+}
+
+// validate checks that the weights are usable
+func (w ScoreWeights) validate() error {
+	if w.Lines < 0 || w.Files < 0 || w.Recency < 0 {
+		return errors.New("weights must not be negative")
+	}
+	if w.Lines+w.Files+w.Recency == 0 {
+		return errors.New("at least one weight must be positive")
+	}
+	if w.HalfLifeDays <= 0 {
+		return errors.New("half-life-days must be positive")
+	}
+	return nil
+}
+
+// assignScores computes each author's contribution score:
+//
+//	score = 100 * (lines*L + files*F + recency*R) / (lines + files + recency)
+//
+// where L and F are the author's line and file counts divided by the largest
+// among the authors, and R = 0.5^(days since last commit / half-life), so an
+// author who committed today has R = 1 and one inactive for a half-life has
+// R = 0.5. Scores range from 0 to 100.
+func (ga *GitAnalyzer) assignScores(authors []AuthorStats, spans map[string]authorSpan, now time.Time) {
+	w := ga.config.ScoreWeights
+	total := w.Lines + w.Files + w.Recency
+	if total == 0 {
+		return
+	}
+
+	maxLines, maxFiles := 0, 0
+	for _, author := range authors {
+		maxLines = max(maxLines, author.LineCount)
+		maxFiles = max(maxFiles, author.FileCount)
+	}
+
+	for i, author := range authors {
+		var lines, files, recency float64
+		if maxLines > 0 {
+			lines = float64(author.LineCount) / float64(maxLines)
+		}
+		if maxFiles > 0 {
+			files = float64(author.FileCount) / float64(maxFiles)
+		}
+		if last := spans[author.Name].Last; last > 0 {
+			days := max(now.Sub(time.Unix(last, 0)).Hours()/24, 0)
+			recency = math.Pow(0.5, days/w.HalfLifeDays)
+		}
+
+		authors[i].Score = 100 * (w.Lines*lines + w.Files*files + w.Recency*recency) / total
+	}
+}
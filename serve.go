@@ -0,0 +1,331 @@
+package main
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/sync/singleflight"
+)
+
+// shutdownTimeout bounds how long the server waits for in-flight requests
+// when asked to stop
+const shutdownTimeout = 30 * time.Second
+
+// Requests are small GETs, so reading one shouldn't take long. Responses
+// have no write timeout since an analysis of a large repository can.
+const (
+	readHeaderTimeout = 10 * time.Second
+	readTimeout       = 30 * time.Second
+)
+
+// serveCacheSize bounds how many responses are cached for HEAD
+const serveCacheSize = 128
+
+// responseCache keeps the most recently used responses, up to its size
+type responseCache struct {
+	size  int
+	order *list.List // of *cachedResponse, most recently used first
+	items map[string]*list.Element
+}
+
+// cachedResponse is a JSON response and the query it answers
+type cachedResponse struct {
+	query string
+	body  []byte
+}
+
+func newResponseCache(size int) *responseCache {
+	return &responseCache{size: size, order: list.New(), items: make(map[string]*list.Element)}
+}
+
+// get returns the cached response for the query and marks it recently used
+func (c *responseCache) get(query string) ([]byte, bool) {
+	elem, ok := c.items[query]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(elem)
+	return elem.Value.(*cachedResponse).body, true
+}
+
+// add caches the response for the query, evicting the least recently used
+// response when the cache is full
+func (c *responseCache) add(query string, body []byte) {
+	if elem, ok := c.items[query]; ok {
+		elem.Value.(*cachedResponse).body = body
+		c.order.MoveToFront(elem)
+		return
+	}
+	c.items[query] = c.order.PushFront(&cachedResponse{query: query, body: body})
+	if c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(*cachedResponse).query)
+	}
+}
+
+// queryConfig applies the /api/authors query parameters to the base
+// configuration. Parameters are named like the flags, with underscores.
+func queryConfig(base Config, query map[string][]string) (Config, error) {
+	config := base
+	get := func(name string) string {
+		if values := query[name]; len(values) > 0 {
+			return values[len(values)-1]
+		}
+		return ""
+	}
+	list := func(name string) []string {
+		var values []string
+		for _, value := range query[name] {
+			for item := range strings.SplitSeq(value, ",") {
+				if item = strings.TrimSpace(item); item != "" {
+					values = append(values, item)
+				}
+			}
+		}
+		return values
+	}
+	number := func(name string, dst *int) error {
+		value := get(name)
+		if value == "" {
+			return nil
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return fmt.Errorf("invalid %s %q (expected a non-negative integer)", name, value)
+		}
+		*dst = n
+		return nil
+	}
+
+	if err := number("min_lines", &config.MinLines); err != nil {
+		return config, err
+	}
+	if err := number("min_files", &config.MinFiles); err != nil {
+		return config, err
+	}
+	if err := number("limit", &config.MaxResults); err != nil {
+		return config, err
+	}
+	config.DateSince = get("since")
+	config.DateUntil = get("until")
+	config.ExcludeAuthor = list("exclude_author")
+	config.IncludeAuthor = list("include_author")
+	config.Languages = list("language")
+
+	if sort := get("sort"); sort != "" {
+		switch SortBy(sort) {
+		case SortByLines, SortByName, SortByFiles:
+			config.SortBy = SortBy(sort)
+		default:
+			return config, fmt.Errorf("invalid sort %q (expected lines, name or files)", sort)
+		}
+	}
+	if credit := get("credit"); credit != "" {
+		switch CreditMode(credit) {
+		case CreditAuthor, CreditCommitter:
+			config.Credit = CreditMode(credit)
+		default:
+			return config, fmt.Errorf("invalid credit %q (expected author or committer)", credit)
+		}
+	}
+
+	return config, nil
+}
+
+// server answers analysis requests over HTTP, caching results by the
+// repository's HEAD commit and the request's parameters
+type server struct {
+	ga     *GitAnalyzer // reads HEAD and logs; not used for analyses
+	config Config       // base configuration of analyses
+	ctx    context.Context
+	flight singleflight.Group
+
+	mu    sync.Mutex
+	head  string
+	cache *responseCache // JSON responses for head, by query
+}
+
+// analyze runs the analysis for the query, or returns the cached response.
+// Identical concurrent requests share one analysis.
+func (s *server) analyze(query map[string][]string, canonical string) ([]byte, error) {
+	config, err := queryConfig(s.config, query)
+	if err != nil {
+		return nil, &ExitError{Code: ExitCodeUsage, Err: err}
+	}
+
+	head, err := s.ga.headCommit(s.ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read HEAD: %w", err)
+	}
+
+	s.mu.Lock()
+	if s.head != head.Hash {
+		// A new commit invalidates every cached result
+		s.head = head.Hash
+		s.cache = newResponseCache(serveCacheSize)
+	}
+	cached, ok := s.cache.get(canonical)
+	s.mu.Unlock()
+	if ok {
+		return cached, nil
+	}
+
+	key := head.Hash + "?" + canonical
+	body, err, _ := s.flight.Do(key, func() (any, error) {
+		ga := NewGitAnalyzer(config)
+		result, err := ga.Analyze(s.ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		var buf strings.Builder
+		if err := ga.outputJSON(&buf, result); err != nil {
+			return nil, err
+		}
+		body := []byte(buf.String())
+
+		s.mu.Lock()
+		if s.head == head.Hash {
+			s.cache.add(canonical, body)
+		}
+		s.mu.Unlock()
+		return body, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return body.([]byte), nil
+}
+
+// handleAuthors serves the author results as JSON
+func (s *server) handleAuthors(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+
+	query := r.URL.Query()
+	body, err := s.analyze(query, query.Encode())
+	if err != nil {
+		status := http.StatusInternalServerError
+		if exitCode(err) == ExitCodeUsage {
+			status = http.StatusBadRequest
+		}
+		s.ga.logWarn("%s %s: %v", r.Method, r.URL, err)
+		writeJSONError(w, status, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(body)
+}
+
+// handleHealth reports that the server is up
+func handleHealth(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte(`{"status":"ok"}` + "\n"))
+}
+
+// writeJSONError writes {"error": "..."} with the given status
+func writeJSONError(w http.ResponseWriter, status int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
+
+// newServeCmd creates the command serving results over HTTP
+func newServeCmd() *cobra.Command {
+	var addr, repo string
+
+	serveCmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Serve analysis results as JSON over HTTP",
+		Long: `Serve analysis results as JSON over HTTP.
+
+Endpoints:
+  GET /api/authors   Author results, as with --output json. Query parameters
+                     min_lines, min_files, limit, since, until, sort, credit,
+                     exclude_author, include_author and language work like
+                     the flags of the same name. language values aren't
+                     validated, since .gitattributes can name any language:
+                     an unknown one matches no files and returns an empty
+                     result rather than an error. Results of recent queries
+                     are cached until HEAD moves.
+  GET /healthz       Health check`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			absRepo, err := filepath.Abs(repo)
+			if err != nil {
+				return fmt.Errorf("invalid --repo path: %w", err)
+			}
+
+			config := defaultConfig(absRepo)
+			logged := config
+			logged.Quiet = false
+			ga := NewGitAnalyzer(logged)
+
+			ctx, stop := signal.NotifyContext(cmd.Context(), syscall.SIGINT, syscall.SIGTERM)
+			defer stop()
+
+			if err := ga.checkGit(ctx); err != nil {
+				return err
+			}
+			if err := ga.validateDirectory(ctx); err != nil {
+				return err
+			}
+
+			// Analyses outlive the signal so in-flight requests can finish
+			analysisCtx, cancelAnalyses := context.WithCancel(context.Background())
+			defer cancelAnalyses()
+
+			s := &server{ga: ga, config: config, ctx: analysisCtx}
+			mux := http.NewServeMux()
+			mux.HandleFunc("/api/authors", s.handleAuthors)
+			mux.HandleFunc("/healthz", handleHealth)
+
+			srv := &http.Server{
+				Addr:              addr,
+				Handler:           mux,
+				ReadHeaderTimeout: readHeaderTimeout,
+				ReadTimeout:       readTimeout,
+			}
+			errChan := make(chan error, 1)
+			go func() {
+				errChan <- srv.ListenAndServe()
+			}()
+			ga.logInfo("Serving %s on %s", absRepo, addr)
+
+			select {
+			case err := <-errChan:
+				return fmt.Errorf("server failed: %w", err)
+			case <-ctx.Done():
+			}
+
+			ga.logInfo("Shutting down")
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+			defer cancel()
+			if err := srv.Shutdown(shutdownCtx); err != nil {
+				return fmt.Errorf("shutdown: %w", err)
+			}
+			return nil
+		},
+	}
+
+	serveCmd.Flags().StringVar(&addr, "addr", ":8080", "Address to listen on")
+	serveCmd.Flags().StringVar(&repo, "repo", ".", "Repository to analyze")
+
+	return serveCmd
+}
@@ -0,0 +1,51 @@
+package main
+
+import "testing"
+
+func TestResponseCacheEviction(t *testing.T) {
+	c := newResponseCache(2)
+	c.add("a", []byte("1"))
+	c.add("b", []byte("2"))
+	if _, ok := c.get("a"); !ok { // a is now more recent than b
+		t.Fatal("a missing before the cache filled")
+	}
+	c.add("c", []byte("3"))
+
+	if _, ok := c.get("b"); ok {
+		t.Error("b, the least recently used, wasn't evicted")
+	}
+	for _, query := range []string{"a", "c"} {
+		if _, ok := c.get(query); !ok {
+			t.Errorf("%s was evicted", query)
+		}
+	}
+
+	c.add("a", []byte("4"))
+	if body, _ := c.get("a"); string(body) != "4" {
+		t.Errorf("a = %q after replacing it, want 4", body)
+	}
+	if c.order.Len() != 2 || len(c.items) != 2 {
+		t.Errorf("cache holds %d responses (%d indexed), want 2", c.order.Len(), len(c.items))
+	}
+}
+
+func TestQueryConfig(t *testing.T) {
+	config, err := queryConfig(defaultConfig("repo"), map[string][]string{
+		"language": {"go, Protocol Buffer", "klingon"},
+		"sort":     {"files"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !config.NoCheckpoint {
+		t.Error("server analyses write --resume checkpoints")
+	}
+	// Any language name is accepted, as .gitattributes can define new ones
+	if len(config.Languages) != 3 || config.SortBy != SortByFiles {
+		t.Errorf("languages = %q, sort = %q", config.Languages, config.SortBy)
+	}
+
+	if _, err := queryConfig(defaultConfig("repo"), map[string][]string{"limit": {"-1"}}); err == nil {
+		t.Error("negative limit accepted")
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// skipStale drops the files not modified since the --modified-within
+// cutoff. By default a file counts as modified when a commit reachable from
+// HEAD and dated after the cutoff changed it, found with a single git log
+// call over those commits only, so the cost grows with the activity in the
+// window rather than the age of the repository. With --modified-by-mtime
+// the filesystem modification time is used instead, one stat per file. When
+// git log fails, every file is kept.
+func (ga *GitAnalyzer) skipStale(ctx context.Context, files []string) []string {
+	cutoff := ga.config.ModifiedSince
+	if cutoff.IsZero() {
+		return files
+	}
+
+	var changed map[string]bool
+	if !ga.config.ModifiedMtime {
+		output, err := ga.gitCommand(ctx, "log", "--format=", "--name-only", "-z", "--no-renames", "--relative",
+			"--since="+cutoff.Format(time.RFC3339), "HEAD").Output()
+		if err != nil {
+			ga.logWarn("Failed to list recently changed files, keeping every file: %v", err)
+			return files
+		}
+
+		changed = make(map[string]bool)
+		for path := range strings.SplitSeq(string(output), "\x00") {
+			if path = strings.TrimLeft(path, "\n"); path != "" {
+				changed[path] = true
+			}
+		}
+	}
+
+	reason := "not modified since " + ga.displayDate(cutoff.UTC().Format(time.DateOnly))
+	kept := files[:0]
+	for _, file := range files {
+		relPath, _ := filepath.Rel(ga.config.Directory, file)
+		fresh := changed[filepath.ToSlash(relPath)]
+		if ga.config.ModifiedMtime {
+			info, err := os.Stat(file)
+			fresh = err != nil || !info.ModTime().Before(cutoff)
+		}
+		if !fresh {
+			ga.skippedStale++
+			ga.explainFile(relPath, reason)
+			continue
+		}
+		kept = append(kept, file)
+	}
+
+	if ga.skippedStale > 0 {
+		ga.logDebug("Skipped %d files %s", ga.skippedStale, reason)
+	}
+	return kept
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// SubmoduleResult holds the separate analysis of a git submodule
+type SubmoduleResult struct {
+	Path   string          `json:"path"`
+	Result *AnalysisResult `json:"result,omitempty"`
+	Error  string          `json:"error,omitempty"`
+}
+
+// isNestedRepository reports whether a directory is the root of another git
+// repository, such as a checked-out submodule whose .git is a file
+func isNestedRepository(path string) bool {
+	_, err := os.Lstat(filepath.Join(path, ".git"))
+	return err == nil
+}
+
+// loadSubmodules reads submodule paths from .gitmodules at the top level of
+// the repository, keeping those inside the analyzed directory relative to it
+func (ga *GitAnalyzer) loadSubmodules() error {
+	file, err := os.Open(filepath.Join(ga.repoRoot, ".gitmodules"))
+	if err != nil {
+		return nil // no submodules
+	}
+	defer file.Close()
+
+	var paths []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(strings.TrimSpace(scanner.Text()), "=")
+		if ok && strings.TrimSpace(key) == "path" {
+			path := strings.Trim(strings.TrimSpace(value), "/")
+			if ga.repoPrefix != "" {
+				if path, ok = strings.CutPrefix(path, ga.repoPrefix+"/"); !ok {
+					continue
+				}
+			}
+			paths = append(paths, filepath.FromSlash(path))
+		}
+	}
+
+	ga.submodules = paths
+	if len(paths) > 0 {
+		ga.logDebug("Found %d submodules in .gitmodules", len(paths))
+	}
+
+	return scanner.Err()
+}
+
+// analyzeSubmodules runs the full analysis inside each checked-out submodule
+func (ga *GitAnalyzer) analyzeSubmodules(ctx context.Context) []SubmoduleResult {
+	results := make([]SubmoduleResult, 0, len(ga.submodules))
+
+	for _, path := range ga.submodules {
+		dir := filepath.Join(ga.config.Directory, path)
+		if !isNestedRepository(dir) {
+			ga.logDebug("Submodule %s is not checked out", path)
+			continue
+		}
+
+		config := ga.config
+		config.Directory = dir
+		config.Resume = false
+		config.Explain = ""
+
+		result, err := NewGitAnalyzer(config).Analyze(ctx)
+		submodule := SubmoduleResult{Path: filepath.ToSlash(path), Result: result}
+		if err != nil {
+			submodule.Error = err.Error()
+			ga.logWarn("Failed to analyze submodule %s: %v", path, err)
+		}
+		results = append(results, submodule)
+	}
+
+	return results
+}
+
+// displaySubmodules displays each submodule's results after the main results.
+// JSON embeds them in the main result and CSV output has a single table, so
+// only the human-readable formats print them separately.
+func (ga *GitAnalyzer) displaySubmodules(result *AnalysisResult) error {
+	if ga.config.OutputFormat != FormatTable && ga.config.OutputFormat != FormatPlain {
+		return nil
+	}
+
+	for _, submodule := range result.Submodules {
+		if submodule.Result == nil || submodule.Result.TotalFiles == 0 {
+			continue
+		}
+
+		fmt.Printf("\n%s\n", ga.styleHeader("Submodule: "+submodule.Path))
+		if err := ga.displayResults(submodule.Result); err != nil {
+			return err
+		}
+		if err := ga.displaySubmodules(submodule.Result); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
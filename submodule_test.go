@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestSubmodules(t *testing.T) {
+	lib := newTestRepo(t)
+	lib.commit("Bob", map[string]string{"lib.go": lines("lib", 4)})
+
+	r := newTestRepo(t)
+	r.commit("Alice", map[string]string{"main.go": lines("main", 2)})
+	r.git("-c", "protocol.file.allow=always", "submodule", "add", "-q", lib.dir, "deps/lib")
+	r.commit("Alice", nil)
+	want := map[string]int{"Alice": 5} // main.go and the three lines of .gitmodules
+
+	// The submodule's files belong to its own history and are skipped
+	result := r.analyze(nil)
+	if got := authorLines(result); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("author lines = %v, want %v", got, want)
+	}
+	if len(result.Submodules) != 0 {
+		t.Errorf("submodules = %+v, want none without --recurse-submodules", result.Submodules)
+	}
+
+	// With --recurse-submodules they are analyzed and reported separately
+	result = r.analyze(func(c *Config) { c.RecurseSubmodules = true })
+	if got := authorLines(result); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("recursing: author lines = %v, want %v", got, want)
+	}
+	if len(result.Submodules) != 1 {
+		t.Fatalf("submodules = %+v, want deps/lib", result.Submodules)
+	}
+	submodule := result.Submodules[0]
+	if submodule.Path != "deps/lib" || submodule.Error != "" || submodule.Result == nil {
+		t.Fatalf("submodule = %+v, want deps/lib analyzed", submodule)
+	}
+	if got, want := authorLines(submodule.Result), map[string]int{"Bob": 4}; fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("submodule author lines = %v, want %v", got, want)
+	}
+}
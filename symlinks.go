@@ -0,0 +1,59 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// isSymlinkedDir reports whether a path found by the walk, which doesn't
+// follow links, is a symbolic link to a directory
+func isSymlinkedDir(path string, info os.FileInfo) bool {
+	if info.Mode()&os.ModeSymlink == 0 {
+		return false
+	}
+	target, err := os.Stat(path)
+	return err == nil && target.IsDir()
+}
+
+// resolveInside resolves the symbolic links in a path and returns the same
+// location as a path under the analyzed directory, or false when it lies
+// outside it
+func (ga *GitAnalyzer) resolveInside(path string) (string, bool) {
+	root, err := filepath.EvalSymlinks(ga.config.Directory)
+	if err != nil {
+		return "", false
+	}
+	real, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return "", false
+	}
+	rel, err := filepath.Rel(root, real)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return filepath.Join(ga.config.Directory, rel), true
+}
+
+// insideDirectory reports whether a file to blame lies within the analyzed
+// directory once the links in its parent directories are resolved. The file
+// itself may be a link, which git blames as the link rather than its target.
+func (ga *GitAnalyzer) insideDirectory(path string) bool {
+	_, ok := ga.resolveInside(filepath.Dir(path))
+	return ok
+}
+
+// followLink returns the directory a symlinked directory leads to, as a path
+// under the analyzed directory, for --follow-symlinks. Links leading outside
+// it are skipped, since git doesn't know the files there.
+func (ga *GitAnalyzer) followLink(link string) (string, bool) {
+	relPath, _ := filepath.Rel(ga.config.Directory, link)
+	dir, ok := ga.resolveInside(link)
+	if !ok {
+		ga.logWarn("Skipping symlinked directory %s: it leads outside the analyzed directory", relPath)
+		ga.explainDir(relPath, "symlinked directory outside the analyzed directory")
+		return "", false
+	}
+	ga.logDebug("Following symlinked directory %s to %s", relPath, dir)
+	return dir, true
+}
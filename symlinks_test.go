@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSymlinkOutsideRepository(t *testing.T) {
+	r := newTestRepo(t)
+	outside := t.TempDir()
+	if err := os.WriteFile(filepath.Join(outside, "secret.go"), []byte("package secret\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(outside, filepath.Join(r.dir, "ext")); err != nil {
+		t.Skipf("symlinks unsupported: %v", err)
+	}
+	r.commit("Alice", map[string]string{"src/a.go": lines("alice", 2)})
+
+	for _, follow := range []bool{false, true} {
+		config := defaultConfig(r.dir)
+		config.FollowSymlinks = follow
+		ga := NewGitAnalyzer(config)
+		ctx := context.Background()
+		if err := ga.validateDirectory(ctx); err != nil {
+			t.Fatal(err)
+		}
+		files, err := ga.findFiles(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := filepath.Join(r.dir, "src", "a.go")
+		if len(files) != 1 || files[0] != want {
+			t.Errorf("follow=%v: findFiles = %v, want [%s]", follow, files, want)
+		}
+	}
+
+	ga := NewGitAnalyzer(defaultConfig(r.dir))
+	if dir, ok := ga.followLink(filepath.Join(r.dir, "ext")); ok {
+		t.Errorf("followLink followed the link outside the repository to %s", dir)
+	}
+}
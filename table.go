@@ -0,0 +1,47 @@
+package main
+
+import (
+	"io"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/olekukonko/tablewriter/renderer"
+	"github.com/olekukonko/tablewriter/tw"
+)
+
+// TableStyle selects how table output is drawn
+type TableStyle string
+
+const (
+	TableDefault    TableStyle = "default"
+	TableRounded    TableStyle = "rounded"
+	TableMarkdown   TableStyle = "markdown"
+	TableBorderless TableStyle = "borderless"
+)
+
+// newTable creates a table writer drawn in the configured --table-style
+func (ga *GitAnalyzer) newTable(w io.Writer) *tablewriter.Table {
+	switch ga.config.TableStyle {
+	case TableRounded:
+		return tablewriter.NewTable(w, tablewriter.WithRenderer(renderer.NewBlueprint(tw.Rendition{
+			Symbols: tw.NewSymbols(tw.StyleRounded),
+		})))
+	case TableMarkdown:
+		// Markdown takes each column's alignment from the header, which is
+		// centered by default; align left like the rows of the other styles
+		return tablewriter.NewTable(w,
+			tablewriter.WithRenderer(renderer.NewMarkdown()),
+			tablewriter.WithHeaderAlignment(tw.AlignLeft),
+			tablewriter.WithRowAlignment(tw.AlignLeft))
+	case TableBorderless:
+		return tablewriter.NewTable(w, tablewriter.WithRenderer(renderer.NewBlueprint(tw.Rendition{
+			Borders: tw.BorderNone,
+			Symbols: tw.NewSymbols(tw.StyleNone),
+			Settings: tw.Settings{
+				Lines:      tw.LinesNone,
+				Separators: tw.Separators{BetweenColumns: tw.Off},
+			},
+		})))
+	default:
+		return tablewriter.NewWriter(w)
+	}
+}
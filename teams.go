@@ -0,0 +1,136 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// UnassignedTeam collects the authors who aren't a member of any team
+const UnassignedTeam = "Unassigned"
+
+// Team groups authors, identified by name or email, under a single label
+type Team struct {
+	Name    string   `mapstructure:"name"`
+	Members []string `mapstructure:"members"`
+}
+
+// TeamStats represents the aggregated contributions of a team
+type TeamStats struct {
+	Name       string   `json:"name"`
+	LineCount  int      `json:"line_count"`
+	FileCount  int      `json:"file_count"`
+	Percentage float64  `json:"percentage"`
+	Members    []string `json:"members"`
+}
+
+// teamOf resolves the team an author belongs to by name or by any of the
+// emails they committed with. The first matching team wins.
+func (ga *GitAnalyzer) teamOf(name string, emails map[string]bool) string {
+	for _, team := range ga.config.Teams {
+		for _, member := range team.Members {
+			member = strings.TrimSuffix(strings.TrimPrefix(member, "<"), ">")
+			if strings.EqualFold(member, name) {
+				return team.Name
+			}
+			for email := range emails {
+				if strings.EqualFold(member, email) {
+					return team.Name
+				}
+			}
+		}
+	}
+	return UnassignedTeam
+}
+
+// aggregateTeams rolls the per-author tally up into teams, largest first
+func (ga *GitAnalyzer) aggregateTeams(authorCounts map[string]int, authorWeighted map[string]float64, authorFiles, authorEmails map[string]map[string]bool, denominator float64) []TeamStats {
+	byName := make(map[string]*TeamStats)
+	teamFiles := make(map[string]map[string]bool)
+	teamShares := make(map[string]float64)
+
+	for author, count := range authorCounts {
+		name := ga.teamOf(author, authorEmails[author])
+		team := byName[name]
+		if team == nil {
+			team = &TeamStats{Name: name}
+			byName[name] = team
+			teamFiles[name] = make(map[string]bool)
+		}
+
+		team.LineCount += count
+		if ga.config.Weighted {
+			teamShares[name] += authorWeighted[author]
+		} else {
+			teamShares[name] += float64(count)
+		}
+		team.Members = append(team.Members, author)
+		for file := range authorFiles[author] {
+			teamFiles[name][file] = true
+		}
+	}
+
+	teams := make([]TeamStats, 0, len(byName))
+	for name, team := range byName {
+		team.FileCount = len(teamFiles[name])
+		if denominator > 0 {
+			team.Percentage = teamShares[name] / denominator * 100
+		}
+		sort.Strings(team.Members)
+		teams = append(teams, *team)
+	}
+
+	sort.Slice(teams, func(i, j int) bool {
+		if teams[i].LineCount != teams[j].LineCount {
+			return teams[i].LineCount > teams[j].LineCount
+		}
+		return teams[i].Name < teams[j].Name
+	})
+
+	return teams
+}
+
+// displayTeamResults shows lines aggregated by team
+func (ga *GitAnalyzer) displayTeamResults(w io.Writer, result *AnalysisResult) error {
+	if !ga.config.Quiet {
+		fmt.Fprintf(w, "\n%s\n", ga.styleHeader("Team Contributions"))
+	}
+
+	if len(result.Teams) == 0 {
+		if !ga.config.Quiet {
+			ga.logWarn("No authors found matching criteria")
+		}
+		return nil
+	}
+
+	table := ga.newTable(w)
+	headers := []string{"Lines", "Files", "Percentage", "Members", "Team"}
+	showRank := ga.rankStyle() != RankNone
+	if showRank {
+		headers = append([]string{"Rank"}, headers...)
+	}
+	table.Header(headers)
+
+	for i, team := range result.Teams {
+		row := []string{
+			ga.formatNumber(team.LineCount),
+			ga.formatNumber(team.FileCount),
+			ga.formatPercent(team.Percentage, 1),
+			ga.formatNumber(len(team.Members)),
+			team.Name,
+		}
+		if showRank {
+			row = append([]string{ga.rankLabel(i)}, row...)
+		}
+		table.Append(row)
+	}
+
+	table.Render()
+
+	if !ga.config.Quiet {
+		ga.displaySummary(w, result)
+	}
+
+	return nil
+}
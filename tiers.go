@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// defaultTierThresholds are the percentages separating core, regular and
+// occasional contributors with --tiers
+var defaultTierThresholds = []float64{10, 1}
+
+// tierNames name the tiers, from the largest contributors down
+var tierNames = []string{"Core", "Regular", "Occasional"}
+
+// tierMembersShown is how many members of a tier the table lists before
+// summarizing the rest
+const tierMembersShown = 5
+
+// TierStats represents the authors whose share of lines falls in a band.
+// Core authors own more than the maximum of the regular tier, and occasional
+// authors less than its minimum.
+type TierStats struct {
+	Name          string   `json:"name"`
+	MinPercentage float64  `json:"min_percentage"`
+	MaxPercentage float64  `json:"max_percentage"`
+	Authors       int      `json:"authors"`
+	LineCount     int      `json:"line_count"`
+	Percentage    float64  `json:"percentage"`
+	Members       []string `json:"members"`
+}
+
+// assignTiers groups the sorted authors into tiers by percentage, keeping
+// their order within each tier. Every tier is listed, even when empty.
+func (ga *GitAnalyzer) assignTiers(authors []AuthorStats) []TierStats {
+	high, low := ga.config.TierThresholds[0], ga.config.TierThresholds[1]
+	tiers := []TierStats{
+		{Name: tierNames[0], MinPercentage: high, MaxPercentage: 100, Members: []string{}},
+		{Name: tierNames[1], MinPercentage: low, MaxPercentage: high, Members: []string{}},
+		{Name: tierNames[2], MinPercentage: 0, MaxPercentage: low, Members: []string{}},
+	}
+
+	for _, author := range authors {
+		tier := &tiers[2]
+		switch {
+		case author.Percentage > high:
+			tier = &tiers[0]
+		case author.Percentage >= low:
+			tier = &tiers[1]
+		}
+		tier.Authors++
+		tier.LineCount += author.LineCount
+		tier.Percentage += author.Percentage
+		tier.Members = append(tier.Members, author.Name)
+	}
+	return tiers
+}
+
+// tierRange describes a tier's band of percentages, such as ">10%"
+func (ga *GitAnalyzer) tierRange(i int, tier TierStats) string {
+	switch i {
+	case 0:
+		return fmt.Sprintf(">%g%%", tier.MinPercentage)
+	case len(tierNames) - 1:
+		return fmt.Sprintf("<%g%%", tier.MaxPercentage)
+	}
+	return fmt.Sprintf("%g–%g%%", tier.MinPercentage, tier.MaxPercentage)
+}
+
+// displayTierResults displays authors grouped into tiers
+func (ga *GitAnalyzer) displayTierResults(w io.Writer, result *AnalysisResult) error {
+	if !ga.config.Quiet {
+		fmt.Fprintf(w, "\n%s\n", ga.styleHeader("Contributor Tiers"))
+	}
+
+	if len(result.Authors) == 0 {
+		if !ga.config.Quiet {
+			ga.logWarn("No authors found matching criteria")
+		}
+		return nil
+	}
+
+	table := ga.newTable(w)
+	table.Header([]string{"Tier", "Authors", "Lines", "Percentage", "Members"})
+	for i, tier := range result.Tiers {
+		members := tier.Members
+		if len(members) > tierMembersShown {
+			members = append(members[:tierMembersShown:tierMembersShown],
+				fmt.Sprintf("and %s more", ga.formatNumber(len(tier.Members)-tierMembersShown)))
+		}
+		table.Append([]string{
+			fmt.Sprintf("%s (%s)", tier.Name, ga.tierRange(i, tier)),
+			ga.formatNumber(tier.Authors),
+			ga.formatNumber(tier.LineCount),
+			ga.formatPercent(tier.Percentage, 1),
+			strings.Join(members, ", "),
+		})
+	}
+	table.Render()
+
+	if !ga.config.Quiet {
+		ga.displaySummary(w, result)
+	}
+
+	return nil
+}
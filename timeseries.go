@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// InfluxMeasurement is the measurement name of --output influx lines
+const InfluxMeasurement = "gala_ownership"
+
+// CommitRef identifies the commit an analysis ran against
+type CommitRef struct {
+	Hash string    `json:"hash"`
+	Time time.Time `json:"time"`
+}
+
+// Label is a key=value dimension stamped on every time-series record
+type Label struct {
+	Key   string
+	Value string
+}
+
+// parseLabels parses --label key=value pairs, sorted by key as InfluxDB
+// recommends for tags
+func parseLabels(values []string) ([]Label, error) {
+	labels := make([]Label, 0, len(values))
+	seen := make(map[string]bool)
+	for _, value := range values {
+		key, val, ok := strings.Cut(value, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("%q is not key=value", value)
+		}
+		if seen[key] {
+			return nil, fmt.Errorf("duplicate label %q", key)
+		}
+		switch key {
+		case "repo", "author":
+			return nil, fmt.Errorf("label %q is reserved", key)
+		}
+		seen[key] = true
+		labels = append(labels, Label{Key: key, Value: val})
+	}
+
+	sort.Slice(labels, func(i, j int) bool { return labels[i].Key < labels[j].Key })
+	return labels, nil
+}
+
+// headCommit looks up the commit checked out in the repository
+func (ga *GitAnalyzer) headCommit(ctx context.Context) (*CommitRef, error) {
+	output, err := ga.gitCommand(ctx, "log", "-1", "--format=%H %ct", "HEAD").Output()
+	if err != nil {
+		return nil, err
+	}
+
+	hash, stamp, _ := strings.Cut(strings.TrimSpace(string(output)), " ")
+	seconds, err := strconv.ParseInt(stamp, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("unexpected git log output %q", output)
+	}
+	return &CommitRef{Hash: hash, Time: time.Unix(seconds, 0).UTC()}, nil
+}
+
+// sampleTime is the timestamp of time-series records: the commit time of
+// HEAD, so runs at successive tags line up on the commit timeline, or the
+// analysis time when HEAD is unknown
+func (result *AnalysisResult) sampleTime() time.Time {
+	if result.Head != nil {
+		return result.Head.Time
+	}
+	return result.GeneratedAt
+}
+
+// influxEscape escapes a tag key or value for the InfluxDB line protocol
+var influxEscape = strings.NewReplacer(`,`, `\,`, `=`, `\=`, ` `, `\ `)
+
+// outputInflux outputs one InfluxDB line protocol point per author:
+//
+//	gala_ownership,repo=gala,author=Alice,tag=v1.2 lines=1507i,files=3i,pct=98.69 1700000000000000000
+func (ga *GitAnalyzer) outputInflux(w io.Writer, result *AnalysisResult) error {
+	var tags strings.Builder
+	tags.WriteString(",repo=" + influxEscape.Replace(filepath.Base(result.Repository)))
+	for _, label := range ga.labels {
+		tags.WriteString("," + influxEscape.Replace(label.Key) + "=" + influxEscape.Replace(label.Value))
+	}
+
+	stamp := result.sampleTime().UnixNano()
+	for _, author := range result.Authors {
+		_, err := fmt.Fprintf(w, "%s%s,author=%s lines=%di,files=%di,pct=%s %d\n",
+			InfluxMeasurement, tags.String(), influxEscape.Replace(author.Name),
+			author.LineCount, author.FileCount,
+			strconv.FormatFloat(author.Percentage, 'f', -1, 64), stamp)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// jsonlRecord is one author's sample in --output jsonl
+type jsonlRecord struct {
+	Timestamp time.Time         `json:"timestamp"`
+	Commit    string            `json:"commit,omitempty"`
+	Repo      string            `json:"repo"`
+	Labels    map[string]string `json:"labels,omitempty"`
+	Author    string            `json:"author"`
+	Lines     int               `json:"lines"`
+	Files     int               `json:"files"`
+	Pct       float64           `json:"pct"`
+}
+
+// outputJSONL outputs one JSON object per author and line, stamped with the
+// timestamp, commit and --label dimensions
+func (ga *GitAnalyzer) outputJSONL(w io.Writer, result *AnalysisResult) error {
+	var labels map[string]string
+	if len(ga.labels) > 0 {
+		labels = make(map[string]string, len(ga.labels))
+		for _, label := range ga.labels {
+			labels[label.Key] = label.Value
+		}
+	}
+
+	record := jsonlRecord{
+		Timestamp: result.sampleTime(),
+		Repo:      filepath.Base(result.Repository),
+		Labels:    labels,
+	}
+	if result.Head != nil {
+		record.Commit = result.Head.Hash
+	}
+
+	encoder := json.NewEncoder(w)
+	for _, author := range result.Authors {
+		record.Author = author.Name
+		record.Lines = author.LineCount
+		record.Files = author.FileCount
+		record.Pct = author.Percentage
+		if err := encoder.Encode(record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+)
+
+// trackedPaths lists the files git tracks, including ones only staged so
+// far, by their slash-separated paths relative to the analyzed directory.
+// They come from a single git ls-files call.
+func (ga *GitAnalyzer) trackedPaths(ctx context.Context) (map[string]bool, error) {
+	output, err := ga.gitCommand(ctx, "ls-files", "-z").Output()
+	if err != nil {
+		return nil, err
+	}
+
+	tracked := make(map[string]bool)
+	for path := range strings.SplitSeq(string(output), "\x00") {
+		tracked[path] = true
+	}
+	return tracked, nil
+}
+
+// skipUntracked drops the walked files git doesn't track, which blame can't
+// resolve, so they're reported as untracked instead of failing as errors.
+// When the tracked files couldn't be listed, every file is kept.
+func (ga *GitAnalyzer) skipUntracked(files []string, tracked map[string]bool) []string {
+	if tracked == nil {
+		return files
+	}
+
+	kept := files[:0]
+	for _, file := range files {
+		relPath, _ := filepath.Rel(ga.config.Directory, file)
+		if !tracked[filepath.ToSlash(relPath)] {
+			ga.skippedUntracked++
+			ga.logDebug("Skipping untracked file: %s", relPath)
+			ga.explainFile(relPath, "untracked")
+			continue
+		}
+		kept = append(kept, file)
+	}
+	return kept
+}
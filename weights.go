@@ -0,0 +1,193 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// WeightStrategy is one stage of the weighting pipeline. The weights of all
+// selected strategies are multiplied together for each line.
+type WeightStrategy string
+
+const (
+	WeightPathGlob       WeightStrategy = "path-glob"       // the config's weights rules, per file
+	WeightRecencyDecay   WeightStrategy = "recency-decay"   // halves with each half-life of a line's age
+	WeightFileImportance WeightStrategy = "file-importance" // 1 to 2 by how often a file changes
+)
+
+// defaultDecayHalfLife is the --decay-half-life default, in days
+const defaultDecayHalfLife = 365
+
+// parseWeightStrategies validates --weight values
+func parseWeightStrategies(values []string) ([]WeightStrategy, error) {
+	strategies := make([]WeightStrategy, 0, len(values))
+	for _, value := range values {
+		switch s := WeightStrategy(strings.TrimSpace(value)); s {
+		case WeightPathGlob, WeightRecencyDecay, WeightFileImportance:
+			strategies = append(strategies, s)
+		default:
+			return nil, fmt.Errorf("unknown strategy %q (expected path-glob, recency-decay or file-importance)", value)
+		}
+	}
+	return strategies, nil
+}
+
+// weighting reports whether the strategy is part of the pipeline
+func (ga *GitAnalyzer) weighting(strategy WeightStrategy) bool {
+	for _, s := range ga.config.WeightStrategies {
+		if s == strategy {
+			return true
+		}
+	}
+	return false
+}
+
+// WeightRule assigns a line multiplier to files matching a path glob
+type WeightRule struct {
+	Pattern string  `mapstructure:"pattern"`
+	Weight  float64 `mapstructure:"weight"`
+
+	re *regexp.Regexp
+}
+
+// compileWeights validates weight rules and compiles their globs in place
+func compileWeights(rules []WeightRule) error {
+	for i := range rules {
+		if rules[i].Pattern == "" {
+			return fmt.Errorf("weight rule %d has no pattern", i+1)
+		}
+		if rules[i].Weight < 0 {
+			return fmt.Errorf("weight for %q must not be negative", rules[i].Pattern)
+		}
+
+		re, err := globToRegexp(rules[i].Pattern)
+		if err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", rules[i].Pattern, err)
+		}
+		rules[i].re = re
+	}
+	return nil
+}
+
+// globToRegexp converts a path glob to an anchored regular expression.
+// "**" matches across directories, "*" and "?" stay within one path segment.
+// Patterns without a slash match the file's base name at any depth.
+func globToRegexp(pattern string) (*regexp.Regexp, error) {
+	var b strings.Builder
+	b.WriteString("^")
+	if !strings.Contains(pattern, "/") {
+		b.WriteString("(?:.*/)?")
+	}
+
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch c {
+		case '*':
+			if i+1 < len(pattern) && pattern[i+1] == '*' {
+				i++
+				if i+1 < len(pattern) && pattern[i+1] == '/' {
+					i++
+					b.WriteString("(?:.*/)?")
+				} else {
+					b.WriteString(".*")
+				}
+			} else {
+				b.WriteString("[^/]*")
+			}
+		case '?':
+			b.WriteString("[^/]")
+		case '[':
+			end := strings.IndexByte(pattern[i:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated character class")
+			}
+			class := pattern[i+1 : i+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + class + "]")
+			i += end
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+
+	b.WriteString("$")
+	return regexp.Compile(b.String())
+}
+
+// fileWeight returns the product of the per-file strategies: the multiplier
+// of the first weight rule matching the file, or 1.0 when no rule matches,
+// and the file's importance
+func (ga *GitAnalyzer) fileWeight(filePath string) float64 {
+	if len(ga.config.WeightStrategies) == 0 {
+		return 1.0
+	}
+
+	relPath, err := filepath.Rel(ga.config.Directory, filePath)
+	if err != nil {
+		relPath = filePath
+	}
+	relPath = filepath.ToSlash(relPath)
+
+	weight := 1.0
+	if ga.weighting(WeightPathGlob) {
+		for _, rule := range ga.config.Weights {
+			if rule.re != nil && rule.re.MatchString(relPath) {
+				weight = rule.Weight
+				break
+			}
+		}
+	}
+	if importance, ok := ga.importance[relPath]; ok {
+		weight *= importance
+	}
+	return weight
+}
+
+// decayWeight returns a line's recency weight, 1.0 for a line written now,
+// halving with every --decay-half-life days of age. Lines without a time
+// keep a weight of 1.0.
+func (ga *GitAnalyzer) decayWeight(lineTime int64, now time.Time) float64 {
+	if lineTime <= 0 {
+		return 1.0
+	}
+	ageDays := now.Sub(time.Unix(lineTime, 0)).Hours() / 24
+	if ageDays <= 0 {
+		return 1.0
+	}
+	return math.Pow(0.5, ageDays/ga.config.DecayHalfLife)
+}
+
+// loadImportance weighs each file from 1 to 2 by the number of commits that
+// changed it, on a log scale relative to the most changed file, using a
+// single git log call over the history of HEAD. Paths are relative to the
+// analyzed directory, like the blamed files.
+func (ga *GitAnalyzer) loadImportance(ctx context.Context) error {
+	output, err := ga.gitCommand(ctx, "log", "--format=", "--name-only", "-z", "--no-renames", "--relative").Output()
+	if err != nil {
+		return fmt.Errorf("git log --name-only: %w", err)
+	}
+
+	changes := make(map[string]int)
+	most := 0
+	for path := range strings.SplitSeq(string(output), "\x00") {
+		path = strings.TrimLeft(path, "\n")
+		if path == "" {
+			continue
+		}
+		changes[path]++
+		most = max(most, changes[path])
+	}
+
+	ga.importance = make(map[string]float64, len(changes))
+	for path, n := range changes {
+		ga.importance[path] = 1 + math.Log2(1+float64(n))/math.Log2(1+float64(most))
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// windowSpec matches the --window values: "first-" or "last-" followed by
+// "year" or a number of days such as "90d"
+var windowSpec = regexp.MustCompile(`^(first|last)-(year|[1-9][0-9]*d)$`)
+
+// daysPerYear is the length of the "year" windows
+const daysPerYear = 365
+
+// timeWindow is a span of line dates, in Unix seconds, that --window
+// restricts counting to. Both ends are inclusive.
+type timeWindow struct {
+	From, To int64
+}
+
+// parseWindow splits a --window value into whether it counts from the
+// repository's first commit rather than back from its last, and its length
+func parseWindow(spec string) (first bool, length time.Duration, err error) {
+	m := windowSpec.FindStringSubmatch(spec)
+	if m == nil {
+		return false, 0, fmt.Errorf("expected first-year, last-year, first-<days>d or last-<days>d")
+	}
+	days := daysPerYear
+	if m[2] != "year" {
+		if days, err = strconv.Atoi(strings.TrimSuffix(m[2], "d")); err != nil {
+			return false, 0, err
+		}
+	}
+	return m[1] == "first", time.Duration(days) * 24 * time.Hour, nil
+}
+
+// loadWindow resolves --window against the repository's history. First
+// windows start at the oldest root commit reachable from HEAD and last
+// windows end at HEAD, both dated like the lines (--time-basis).
+func (ga *GitAnalyzer) loadWindow(ctx context.Context) error {
+	first, length, err := parseWindow(ga.config.Window)
+	if err != nil {
+		return err
+	}
+
+	format := "--format=%at"
+	if ga.timeBasis() == TimeCommitter {
+		format = "--format=%ct"
+	}
+	args := []string{"log", format, "-1", "HEAD"}
+	if first {
+		args = []string{"log", format, "--max-parents=0", "HEAD"}
+	}
+	output, err := ga.gitCommand(ctx, args...).Output()
+	if err != nil {
+		return fmt.Errorf("git log: %w", err)
+	}
+
+	// Histories joined by merges have several root commits; the oldest wins
+	var anchor int64
+	for field := range strings.FieldsSeq(string(output)) {
+		t, err := strconv.ParseInt(field, 10, 64)
+		if err != nil {
+			return fmt.Errorf("unexpected git log output %q", field)
+		}
+		if anchor == 0 || t < anchor {
+			anchor = t
+		}
+	}
+	if anchor == 0 {
+		return fmt.Errorf("no commits found")
+	}
+
+	// Last windows stay open-ended, so uncommitted changes, dated now, count
+	seconds := int64(length / time.Second)
+	if first {
+		ga.window = &timeWindow{From: anchor, To: anchor + seconds}
+	} else {
+		ga.window = &timeWindow{From: anchor - seconds, To: math.MaxInt64}
+	}
+	ga.logDebug("Counting lines dated from %s", formatCommitDate(ga.window.From))
+	return nil
+}
+
+// inWindow reports whether a line dated t is counted under --window
+func (ga *GitAnalyzer) inWindow(t int64) bool {
+	return ga.window == nil || (t >= ga.window.From && t <= ga.window.To)
+}